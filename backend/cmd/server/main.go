@@ -7,6 +7,7 @@ import (
 	"github.com/blowjobs-ai/backend/internal/api"
 	"github.com/blowjobs-ai/backend/internal/config"
 	"github.com/blowjobs-ai/backend/internal/database"
+	"github.com/blowjobs-ai/backend/internal/storage"
 	"github.com/blowjobs-ai/backend/internal/websocket"
 	"github.com/joho/godotenv"
 )
@@ -37,12 +38,45 @@ func main() {
 		log.Printf("Warning: v2 migrations failed (may already be applied): %v", err)
 	}
 
-	// Initialize WebSocket hub
-	hub := websocket.NewHub()
+	// Run v3 migrations (job-scoped swipes, swipe undo)
+	if err := database.RunMigrationsV3(db); err != nil {
+		log.Printf("Warning: v3 migrations failed (may already be applied): %v", err)
+	}
+
+	// Run v4 migrations (real-time events, event replay)
+	if err := database.RunMigrationsV4(db); err != nil {
+		log.Printf("Warning: v4 migrations failed (may already be applied): %v", err)
+	}
+
+	// Run v5 migrations (recruiter tooling, hiring pipeline)
+	if err := database.RunMigrationsV5(db); err != nil {
+		log.Printf("Warning: v5 migrations failed (may already be applied): %v", err)
+	}
+
+	// Initialize WebSocket hub, sharing events between replicas through
+	// Postgres when configured
+	var broker websocket.Broker = websocket.NewLocalBroker()
+	if cfg.HubBackend == "postgres" {
+		pgBroker, err := websocket.NewPostgresBroker(db, cfg.DatabaseURL, cfg.HubChannel)
+		if err != nil {
+			log.Fatalf("Failed to start hub broker: %v", err)
+		}
+		defer pgBroker.Close()
+		broker = pgBroker
+	}
+	hub := websocket.NewHubWithBroker(broker)
+	hub.SetEventStore(websocket.NewPostgresEventStore(db))
 	go hub.Run()
 
+	// Initialize file storage (CVs, chat attachments)
+	store, err := storage.NewLocalStorage(cfg.UploadDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
 	// Initialize and start API server
-	server := api.NewServer(db, hub, cfg)
+	server := api.NewServer(db, hub, store, cfg)
+	server.RunWorkers()
 	
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errNoOpenOffer = errors.New("no offer to respond to")
+
+// WithdrawApplication lets the candidate leave the hiring process at any
+// stage before it has ended
+func (s *Server) WithdrawApplication(c *gin.Context) {
+	s.candidateStatusAction(c, models.ApplicationStatusWithdrawn, false)
+}
+
+// AcceptOffer accepts the offer the candidate received
+func (s *Server) AcceptOffer(c *gin.Context) {
+	s.candidateStatusAction(c, models.ApplicationStatusHired, true)
+}
+
+// DeclineOffer turns down the offer, which ends the application
+func (s *Server) DeclineOffer(c *gin.Context) {
+	s.candidateStatusAction(c, models.ApplicationStatusWithdrawn, true)
+}
+
+// candidateStatusAction moves one of the job seeker's matches to status,
+// with an optional reason. The recruiter gets a status message in the
+// conversation and a status_update event.
+func (s *Server) candidateStatusAction(c *gin.Context, status models.ApplicationStatus, needsOffer bool) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "job_seeker" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only job seekers can respond to their application"})
+		return
+	}
+
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	// The body is optional
+	var req struct {
+		Reason  string     `json:"reason"`
+		OfferID *uuid.UUID `json:"offer_id"` // The offer version being answered, if any
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var m *pipelineMatch
+	var notifications []notification
+	err = s.withTx(func(tx *sql.Tx) error {
+		var err error
+		m, err = lockJobSeekerMatch(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		if needsOffer {
+			if m.Status != models.ApplicationStatusOffered {
+				return errNoOpenOffer
+			}
+			answer := models.OfferStatusDeclined
+			if status == models.ApplicationStatusHired {
+				answer = models.OfferStatusAccepted
+			}
+			if err := settleOffer(tx, m.ID, req.OfferID, answer, req.Reason); err != nil {
+				return err
+			}
+		}
+		notifications, err = s.applyCandidateStatusChange(tx, m, status, req.Reason)
+		return err
+	})
+	switch {
+	case err == errMatchNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	case err == errNoOpenOffer:
+		c.JSON(http.StatusConflict, gin.H{"error": "You don't have an open offer for this job"})
+		return
+	case err == errInvalidTransition:
+		respondInvalidTransition(c, m.Status, status)
+		return
+	case err == errOfferRevised:
+		c.JSON(http.StatusConflict, gin.H{"error": "The offer has changed. Review the latest version"})
+		return
+	case err == errOfferExpired:
+		c.JSON(http.StatusGone, gin.H{"error": "This offer has expired"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update application"})
+		return
+	}
+
+	s.sendNotifications(notifications)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Application updated",
+		"application_status": status,
+	})
+}
+
+// lockJobSeekerMatch locks one of the job seeker's active matches for the
+// rest of the transaction, or returns errMatchNotFound
+func lockJobSeekerMatch(tx *sql.Tx, jobSeekerID, matchID uuid.UUID) (*pipelineMatch, error) {
+	m := &pipelineMatch{ID: matchID, JobSeekerID: jobSeekerID}
+	err := tx.QueryRow(`
+		SELECT m.recruiter_id, m.application_status, j.title
+		FROM matches m
+		JOIN jobs j ON j.id = m.job_id
+		WHERE m.id = $1 AND m.job_seeker_id = $2 AND m.status = 'matched'
+		FOR UPDATE OF m
+	`, matchID, jobSeekerID).Scan(&m.RecruiterID, &m.Status, &m.JobTitle)
+	if err == sql.ErrNoRows {
+		return nil, errMatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// applyCandidateStatusChange is applyStatusChange for a change the job
+// seeker makes: the recruiter is the one told about it
+func (s *Server) applyCandidateStatusChange(tx *sql.Tx, m *pipelineMatch, status models.ApplicationStatus, reason string) ([]notification, error) {
+	previous := m.Status
+	if err := changeStatus(tx, m.JobSeekerID, m, status, reason); err != nil {
+		return nil, err
+	}
+
+	content := candidateStatusMessage(previous, status)
+	if reason != "" {
+		content += "\nReason: " + reason
+	}
+	if _, err := insertMessage(tx, m.JobSeekerID, m.ID, models.MessageTypeStatus, content, nil); err != nil {
+		return nil, err
+	}
+
+	return []notification{{
+		userID: m.RecruiterID,
+		message: map[string]interface{}{
+			"type": "status_update",
+			"payload": map[string]interface{}{
+				"match_id":   m.ID.String(),
+				"job_title":  m.JobTitle,
+				"new_status": status,
+				"changed_by": "job_seeker",
+				"reason":     reason,
+			},
+		},
+	}}, nil
+}
+
+func candidateStatusMessage(previous, status models.ApplicationStatus) string {
+	switch {
+	case status == models.ApplicationStatusHired:
+		return "🎉 The candidate accepted your offer!"
+	case previous == models.ApplicationStatusOffered:
+		return "The candidate declined your offer"
+	default:
+		return "The candidate withdrew their application"
+	}
+}
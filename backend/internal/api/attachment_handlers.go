@@ -0,0 +1,352 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/blowjobs-ai/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// attachmentType is a kind of file accepted in chat. The sniffed content
+// must be one of sniffed, so a renamed executable or HTML page is refused.
+// Types sniffed as something too generic to tell apart have verify check
+// the whole file.
+type attachmentType struct {
+	contentType string
+	sniffed     []string
+	verify      func(file io.ReaderAt, size int64) bool
+}
+
+// Office documents have no signature of their own to http.DetectContentType:
+// DOC sniffs as octet-stream like any executable, DOCX as any zip
+var attachmentTypes = map[string]attachmentType{
+	".pdf":  {"application/pdf", []string{"application/pdf"}, nil},
+	".doc":  {"application/msword", []string{"application/octet-stream"}, isOLEFile},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", []string{"application/zip"}, isWordDocument},
+	".png":  {"image/png", []string{"image/png"}, nil},
+	".jpg":  {"image/jpeg", []string{"image/jpeg"}, nil},
+	".jpeg": {"image/jpeg", []string{"image/jpeg"}, nil},
+	".gif":  {"image/gif", []string{"image/gif"}, nil},
+	".webp": {"image/webp", []string{"image/webp"}, nil},
+}
+
+// oleSignature starts every OLE compound file, the container of DOC files
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// storedFile is an uploaded file waiting to be attached to a message
+type storedFile struct {
+	key         string
+	fileName    string
+	contentType string
+	size        int64
+}
+
+// UploadAttachment shares a file in a conversation as an attachment
+// message. The optional caption becomes the message text.
+func (s *Server) UploadAttachment(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	// Check access before reading the upload
+	if _, _, err := s.chatParticipants(userID, matchID); err != nil {
+		if err == errMatchNotFound {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload attachment"})
+		return
+	}
+
+	maxSize := int64(s.cfg.MaxAttachmentMB) << 20
+	// Leave room for the rest of the multipart form
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && file.Size > maxSize) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File too large. Maximum size is %dMB", s.cfg.MaxAttachmentMB)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	fileType, ok := attachmentTypes[ext]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file type. Allowed: PDF, DOC, DOCX, PNG, JPG, GIF and WEBP"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	// Check the content really is what the extension says
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	head = head[:n]
+	if !fileType.contentMatches(head, src, file.Size) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File content doesn't match its type"})
+		return
+	}
+
+	stored := &storedFile{
+		key:         attachmentKey(matchID, ext),
+		fileName:    filepath.Base(file.Filename),
+		contentType: fileType.contentType,
+	}
+	stored.size, err = s.storage.Put(stored.key, io.MultiReader(bytes.NewReader(head), src))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	caption := strings.TrimSpace(c.PostForm("caption"))
+	if caption == "" {
+		caption = stored.fileName
+	}
+
+	s.respondWithAttachment(c, userID, matchID, caption, stored)
+}
+
+// ShareCV shares the candidate's uploaded CV in a conversation
+func (s *Server) ShareCV(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "job_seeker" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only job seekers can share a CV"})
+		return
+	}
+
+	matchID, err := uuid.Parse(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	if _, _, err := s.chatParticipants(userID, matchID); err != nil {
+		if err == errMatchNotFound {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share CV"})
+		return
+	}
+
+	var cvURL sql.NullString
+	var firstName string
+	s.db.QueryRow(`
+		SELECT p.cv_url, u.first_name
+		FROM users u
+		LEFT JOIN job_seeker_profiles p ON p.user_id = u.id
+		WHERE u.id = $1
+	`, userID).Scan(&cvURL, &firstName)
+	if !cvURL.Valid || cvURL.String == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload a CV first"})
+		return
+	}
+
+	cvKey := cvStorageKey(cvURL.String)
+	ext := strings.ToLower(path.Ext(cvKey))
+	fileType, ok := attachmentTypes[ext]
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share CV"})
+		return
+	}
+
+	// Copy the CV so the message keeps it even if the candidate replaces
+	// their CV later
+	src, err := s.storage.Open(cvKey)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "CV file not found. Please upload it again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share CV"})
+		return
+	}
+	defer src.Close()
+
+	stored := &storedFile{
+		key:         attachmentKey(matchID, ext),
+		fileName:    strings.TrimSpace(firstName+" CV") + ext,
+		contentType: fileType.contentType,
+	}
+	stored.size, err = s.storage.Put(stored.key, src)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share CV"})
+		return
+	}
+
+	s.respondWithAttachment(c, userID, matchID, stored.fileName, stored)
+}
+
+// respondWithAttachment posts a stored file as an attachment message. The
+// file is removed again if the message can't be saved.
+func (s *Server) respondWithAttachment(c *gin.Context, userID, matchID uuid.UUID, caption string, stored *storedFile) {
+	msg, err := s.postMessage(userID, matchID, models.MessageTypeAttachment, caption, stored)
+	if err != nil {
+		if delErr := s.storage.Delete(stored.key); delErr != nil {
+			log.Printf("Failed to remove orphaned attachment %s: %v", stored.key, delErr)
+		}
+		if err == errMatchNotFound {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send attachment"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, msg)
+}
+
+// DownloadAttachment streams an attachment to either participant of the
+// match it was shared in
+func (s *Server) DownloadAttachment(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	var fileName, contentType, key string
+	var size int64
+	err = s.db.QueryRow(`
+		SELECT a.file_name, a.content_type, a.size_bytes, a.storage_key
+		FROM message_attachments a
+		JOIN matches m ON m.id = a.match_id
+		JOIN messages msg ON msg.id = a.message_id
+		WHERE a.id = $1 AND a.match_id = $2 AND (m.job_seeker_id = $3 OR m.recruiter_id = $3)
+		  AND msg.deleted_at IS NULL
+	`, attachmentID, matchID, userID).Scan(&fileName, &contentType, &size, &key)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		return
+	}
+
+	file, err := s.storage.Open(key)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": fileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// insertAttachment records a stored file as the attachment of a message
+func insertAttachment(q querier, messageID, matchID, uploaderID uuid.UUID, file *storedFile) (*models.Attachment, error) {
+	attachment := &models.Attachment{
+		FileName:    file.fileName,
+		ContentType: file.contentType,
+		Size:        file.size,
+	}
+	err := q.QueryRow(`
+		INSERT INTO message_attachments (message_id, match_id, uploader_id, file_name, content_type, size_bytes, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, messageID, matchID, uploaderID, file.fileName, file.contentType, file.size, file.key).Scan(&attachment.ID)
+	if err != nil {
+		return nil, err
+	}
+	attachment.URL = attachmentURL(matchID, attachment.ID)
+	return attachment, nil
+}
+
+// contentMatches reports whether a file starting with head really is of
+// this type
+func (t attachmentType) contentMatches(head []byte, file io.ReaderAt, size int64) bool {
+	return sniffMatches(head, t) && (t.verify == nil || t.verify(file, size))
+}
+
+func sniffMatches(head []byte, fileType attachmentType) bool {
+	sniffed := http.DetectContentType(head)
+	for _, allowed := range fileType.sniffed {
+		if sniffed == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// isOLEFile reports whether the file is an OLE compound file
+func isOLEFile(file io.ReaderAt, size int64) bool {
+	header := make([]byte, len(oleSignature))
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return false
+	}
+	return bytes.Equal(header, oleSignature)
+}
+
+// isWordDocument reports whether the file is a zip holding a Word document's
+// OOXML parts
+func isWordDocument(file io.ReaderAt, size int64) bool {
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return false
+	}
+	var contentTypes, document bool
+	for _, f := range archive.File {
+		switch f.Name {
+		case "[Content_Types].xml":
+			contentTypes = true
+		case "word/document.xml":
+			document = true
+		}
+	}
+	return contentTypes && document
+}
+
+func attachmentKey(matchID uuid.UUID, ext string) string {
+	return fmt.Sprintf("attachments/%s/%s%s", matchID, uuid.New(), ext)
+}
+
+func attachmentURL(matchID, attachmentID uuid.UUID) string {
+	return fmt.Sprintf("/api/v1/chat/%s/attachments/%s", matchID, attachmentID)
+}
+
+// cvStorageKey maps a profile's cv_url ("/uploads/cv/<file>") to its
+// storage key
+func cvStorageKey(cvURL string) string {
+	return strings.TrimPrefix(cvURL, "/uploads/")
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func zipFile(t *testing.T, names ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("<xml/>"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAttachmentContentMatches(t *testing.T) {
+	ole := append(append([]byte{}, oleSignature...), make([]byte, 504)...)
+	elf := append([]byte{0x7F, 'E', 'L', 'F', 2, 1, 1}, make([]byte, 505)...)
+	pe := append([]byte("MZ\x90\x00\x03"), make([]byte, 507)...)
+
+	tests := []struct {
+		name string
+		ext  string
+		file []byte
+		want bool
+	}{
+		{"doc", ".doc", ole, true},
+		{"ELF renamed to doc", ".doc", elf, false},
+		{"PE renamed to doc", ".doc", pe, false},
+		{"docx", ".docx", zipFile(t, "[Content_Types].xml", "_rels/.rels", "word/document.xml"), true},
+		{"zip renamed to docx", ".docx", zipFile(t, "payload.exe"), false},
+		{"xlsx renamed to docx", ".docx", zipFile(t, "[Content_Types].xml", "xl/workbook.xml"), false},
+		{"doc renamed to docx", ".docx", ole, false},
+		{"pdf", ".pdf", []byte("%PDF-1.7\n"), true},
+		{"html renamed to pdf", ".pdf", []byte("<html><script>alert(1)</script>"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			head := tt.file
+			if len(head) > 512 {
+				head = head[:512]
+			}
+			got := attachmentTypes[tt.ext].contentMatches(head, bytes.NewReader(tt.file), int64(len(tt.file)))
+			if got != tt.want {
+				t.Errorf("contentMatches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -161,12 +161,13 @@ func (s *Server) GetCurrentUser(c *gin.Context) {
 	err := s.db.QueryRow(`
 		SELECT id, email, first_name, user_type, is_active, 
 		       swipe_streak, total_swipes, total_matches, badges, 
-		       last_login_at, created_at, updated_at
+		       last_login_at, created_at, updated_at, COALESCE(hide_presence, false)
 		FROM users WHERE id = $1
 	`, userID).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.UserType,
 		&user.IsActive, &user.SwipeStreak, &user.TotalSwipes, &user.TotalMatches,
 		pq.Array(&user.Badges), &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+		&user.HidePresence,
 	)
 
 	if err == sql.ErrNoRows {
@@ -184,8 +185,10 @@ func (s *Server) GetCurrentUser(c *gin.Context) {
 func (s *Server) UpdateCurrentUser(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
+	// Omitted fields are left unchanged
 	var req struct {
-		FirstName string `json:"first_name"`
+		FirstName    *string `json:"first_name"`
+		HidePresence *bool   `json:"hide_presence"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -193,8 +196,12 @@ func (s *Server) UpdateCurrentUser(c *gin.Context) {
 	}
 
 	_, err := s.db.Exec(`
-		UPDATE users SET first_name = $1, updated_at = $2 WHERE id = $3
-	`, req.FirstName, time.Now(), userID)
+		UPDATE users SET
+			first_name = COALESCE($1, first_name),
+			hide_presence = COALESCE($2, hide_presence),
+			updated_at = $3
+		WHERE id = $4
+	`, req.FirstName, req.HidePresence, time.Now(), userID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
@@ -234,10 +241,18 @@ func (s *Server) GetUserStats(c *gin.Context) {
 
 	// Get pending messages
 	s.db.QueryRow(`
-		SELECT COALESCE(SUM(unread_count), 0) FROM matches 
+		SELECT COALESCE(SUM(CASE WHEN job_seeker_id = $1 THEN job_seeker_unread_count ELSE recruiter_unread_count END), 0)
+		FROM matches 
 		WHERE (job_seeker_id = $1 OR recruiter_id = $1) AND status = 'matched'
 	`, userID).Scan(&stats.PendingMessages)
 
+	// Super likes left today
+	stats.SuperLikesQuota = s.cfg.SuperLikesPerDay
+	usedSuperLikes, _ := superLikesUsedToday(s.db, userID)
+	if remaining := stats.SuperLikesQuota - usedSuperLikes; remaining > 0 {
+		stats.SuperLikesRemaining = remaining
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
 
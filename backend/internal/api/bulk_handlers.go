@@ -0,0 +1,324 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// bulkMaxMatches caps how many matches one bulk request may touch
+const bulkMaxMatches = 500
+
+var errTooManyBulkMatches = fmt.Errorf("a bulk request can change at most %d matches", bulkMaxMatches)
+
+// bulkRequest applies a status change and/or a message to the matches of
+// one job selected by filter
+type bulkRequest struct {
+	ApplicationStatus models.ApplicationStatus `json:"application_status"`
+	Reason            string                   `json:"reason"`
+	Content           string                   `json:"content"`
+	TemplateID        *uuid.UUID               `json:"template_id"`
+	Filter            struct {
+		ApplicationStatuses []models.ApplicationStatus `json:"application_statuses"` // Only matches currently in one of these
+		MatchIDs            []uuid.UUID                `json:"match_ids"`            // Only these matches
+	} `json:"filter"`
+}
+
+// bulkMatchResult is what a bulk request did to one match
+type bulkMatchResult struct {
+	MatchID   uuid.UUID  `json:"match_id"`
+	Result    string     `json:"result"` // updated, unchanged, messaged, skipped or not_found
+	Reason    string     `json:"reason,omitempty"`
+	MessageID *uuid.UUID `json:"message_id,omitempty"`
+}
+
+type bulkResponse struct {
+	JobID    uuid.UUID         `json:"job_id"`
+	Matched  int               `json:"matched"`
+	Updated  int               `json:"updated"`
+	Messaged int               `json:"messaged"`
+	Results  []bulkMatchResult `json:"results"`
+}
+
+// BulkUpdateStatus moves a job's selected matches to one application status,
+// optionally followed by a message in each conversation. Matches already in
+// that status are left alone.
+func (s *Server) BulkUpdateStatus(c *gin.Context) {
+	s.runBulk(c, "bulk_status", true)
+}
+
+// BulkMessage sends a message, plain or from a template, to a job's
+// selected matches
+func (s *Server) BulkMessage(c *gin.Context) {
+	s.runBulk(c, "bulk_message", false)
+}
+
+func (s *Server) runBulk(c *gin.Context, scope string, changeStatus bool) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can update matches in bulk"})
+		return
+	}
+
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	var req bulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if changeStatus && req.ApplicationStatus == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "application_status is required"})
+		return
+	}
+	if changeStatus && !req.ApplicationStatus.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application status"})
+		return
+	}
+	if msg := recruiterStatusError(req.ApplicationStatus); changeStatus && msg != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+		return
+	}
+	if !changeStatus {
+		req.ApplicationStatus = ""
+		req.Reason = ""
+	}
+	if req.Content != "" && req.TemplateID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either content or template_id, not both"})
+		return
+	}
+	if !changeStatus && req.Content == "" && req.TemplateID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either content or template_id"})
+		return
+	}
+	// An empty list would select nothing; leaving the filter out selects
+	// every match, so refuse to guess which was meant
+	if req.Filter.MatchIDs != nil && len(req.Filter.MatchIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filter.match_ids is empty. Leave it out to select every match"})
+		return
+	}
+	if req.Filter.ApplicationStatuses != nil && len(req.Filter.ApplicationStatuses) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "filter.application_statuses is empty. Leave it out to select every status"})
+		return
+	}
+
+	var ownsJob bool
+	if err := s.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND recruiter_id = $2)
+	`, jobID, userID).Scan(&ownsJob); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update matches"})
+		return
+	}
+	if !ownsJob {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found or you don't have permission"})
+		return
+	}
+
+	var template string
+	if req.TemplateID != nil {
+		err := s.db.QueryRow(`
+			SELECT content FROM message_templates WHERE id = $1 AND recruiter_id = $2
+		`, *req.TemplateID, userID).Scan(&template)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load template"})
+			return
+		}
+	}
+
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	requestJSON, _ := json.Marshal(req)
+
+	// Everything is applied in one transaction, so a failed request changes
+	// nothing and can simply be retried
+	var response *bulkResponse
+	var notifications []notification
+	var replay *idempotentResponse
+	err = s.withTx(func(tx *sql.Tx) error {
+		response, notifications, replay = nil, nil, nil
+
+		if idempotencyKey != "" {
+			stored, err := claimIdempotencyKey(tx, userID, scope, idempotencyKey, hashRequest(requestJSON))
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				replay = stored
+				return nil
+			}
+		}
+
+		var err error
+		response, notifications, err = s.applyBulk(tx, userID, jobID, &req, template)
+		if err != nil {
+			return err
+		}
+
+		if idempotencyKey != "" {
+			body, _ := json.Marshal(response)
+			return saveIdempotentResponse(tx, userID, scope, idempotencyKey, http.StatusOK, body)
+		}
+		return nil
+	})
+
+	switch {
+	case errors.Is(err, errIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+		return
+	case errors.Is(err, errTooManyBulkMatches):
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("More than %d matches selected. Narrow the filter", bulkMaxMatches)})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update matches"})
+		return
+	}
+
+	if replay != nil {
+		c.Data(replay.status, "application/json; charset=utf-8", replay.body)
+		return
+	}
+
+	s.sendNotifications(notifications)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// applyBulk locks the selected matches in ID order, so concurrent bulk
+// requests can't deadlock, and applies the request to each. A filter left
+// out is sent as NULL and selects everything; empty filters are rejected
+// before this.
+func (s *Server) applyBulk(tx *sql.Tx, recruiterID, jobID uuid.UUID, req *bulkRequest, template string) (*bulkResponse, []notification, error) {
+	var statuses, matchIDs []string
+	if req.Filter.ApplicationStatuses != nil {
+		statuses = make([]string, 0, len(req.Filter.ApplicationStatuses))
+		for _, status := range req.Filter.ApplicationStatuses {
+			statuses = append(statuses, string(status))
+		}
+	}
+	if req.Filter.MatchIDs != nil {
+		matchIDs = make([]string, 0, len(req.Filter.MatchIDs))
+		for _, id := range req.Filter.MatchIDs {
+			matchIDs = append(matchIDs, id.String())
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT m.id, m.job_seeker_id, m.application_status, j.title
+		FROM matches m
+		JOIN jobs j ON j.id = m.job_id
+		WHERE m.job_id = $1 AND m.recruiter_id = $2 AND m.status = 'matched'
+		  AND ($3::text[] IS NULL OR m.application_status = ANY($3::text[]))
+		  AND ($4::uuid[] IS NULL OR m.id = ANY($4::uuid[]))
+		ORDER BY m.id
+		FOR UPDATE OF m
+	`, jobID, recruiterID, pq.StringArray(statuses), pq.StringArray(matchIDs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var matches []*pipelineMatch
+	for rows.Next() {
+		m := &pipelineMatch{RecruiterID: recruiterID}
+		if err := rows.Scan(&m.ID, &m.JobSeekerID, &m.Status, &m.JobTitle); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		matches = append(matches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(matches) > bulkMaxMatches {
+		return nil, nil, errTooManyBulkMatches
+	}
+
+	var senderName string
+	if err := tx.QueryRow(`SELECT first_name FROM users WHERE id = $1`, recruiterID).Scan(&senderName); err != nil {
+		return nil, nil, err
+	}
+
+	response := &bulkResponse{JobID: jobID, Matched: len(matches), Results: []bulkMatchResult{}}
+	var notifications []notification
+	found := make(map[uuid.UUID]bool)
+
+	for _, m := range matches {
+		found[m.ID] = true
+		result := bulkMatchResult{MatchID: m.ID}
+
+		if req.ApplicationStatus != "" {
+			if m.Status == req.ApplicationStatus {
+				result.Result = "unchanged"
+				response.Results = append(response.Results, result)
+				continue
+			}
+			if !m.Status.CanTransitionTo(req.ApplicationStatus) {
+				// Neither the status nor the message is applied
+				result.Result = "skipped"
+				result.Reason = fmt.Sprintf("Can't move an application from %s to %s", m.Status, req.ApplicationStatus)
+				response.Results = append(response.Results, result)
+				continue
+			}
+			statusNotifications, err := s.applyStatusChange(tx, recruiterID, m, req.ApplicationStatus, req.Reason)
+			if err != nil {
+				return nil, nil, err
+			}
+			notifications = append(notifications, statusNotifications...)
+			result.Result = "updated"
+			response.Updated++
+		}
+
+		content := req.Content
+		if req.TemplateID != nil {
+			vars, err := templateVars(tx, recruiterID, m.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			content = renderTemplate(template, vars)
+		}
+		if content != "" {
+			msg, err := insertMessage(tx, recruiterID, m.ID, models.MessageTypeText, content, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			notifications = append(notifications, newMessageNotification(m.JobSeekerID, senderName, msg))
+			result.MessageID = &msg.ID
+			if result.Result == "" {
+				result.Result = "messaged"
+			}
+			response.Messaged++
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	// Report requested matches that weren't selected
+	for _, id := range req.Filter.MatchIDs {
+		if !found[id] {
+			found[id] = true
+			response.Results = append(response.Results, bulkMatchResult{
+				MatchID: id,
+				Result:  "not_found",
+				Reason:  "Not an active match for this job, or filtered out by status",
+			})
+		}
+	}
+
+	return response, notifications, nil
+}
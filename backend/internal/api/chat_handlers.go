@@ -1,8 +1,13 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/blowjobs-ai/backend/internal/models"
@@ -14,45 +19,41 @@ func (s *Server) GetConversations(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 	userType := c.MustGet("user_type").(string)
 
-	// Fix: Count unread messages from the OTHER user only (not your own messages)
+	// Unread counts are kept per participant, so each side sees its own
 	var query string
 	if userType == "job_seeker" {
 		query = `
 			SELECT m.id, u.first_name, j.title, j.company_name,
-			       COALESCE(unread.cnt, 0) as unread_count, m.application_status, m.updated_at,
-			       msg.content as last_message
+			       COALESCE(m.job_seeker_unread_count, 0) as unread_count, m.application_status, m.updated_at,
+			       msg.content as last_message,
+			       u.id, u.last_seen_at, COALESCE(u.hide_presence, false),
+			       ` + onlineSQL("u") + `
 			FROM matches m
 			JOIN jobs j ON j.id = m.job_id
 			JOIN users u ON u.id = m.recruiter_id
 			LEFT JOIN LATERAL (
 				SELECT content FROM messages 
-				WHERE match_id = m.id 
+				WHERE match_id = m.id AND deleted_at IS NULL
 				ORDER BY created_at DESC LIMIT 1
 			) msg ON true
-			LEFT JOIN LATERAL (
-				SELECT COUNT(*) as cnt FROM messages 
-				WHERE match_id = m.id AND sender_id != $1 AND is_read = false
-			) unread ON true
 			WHERE m.job_seeker_id = $1 AND m.status = 'matched'
 			ORDER BY COALESCE(m.last_message_at, m.matched_at) DESC
 		`
 	} else {
 		query = `
 			SELECT m.id, u.first_name, j.title, j.company_name,
-			       COALESCE(unread.cnt, 0) as unread_count, m.application_status, m.updated_at,
-			       msg.content as last_message
+			       COALESCE(m.recruiter_unread_count, 0) as unread_count, m.application_status, m.updated_at,
+			       msg.content as last_message,
+			       u.id, u.last_seen_at, COALESCE(u.hide_presence, false),
+			       ` + onlineSQL("u") + `
 			FROM matches m
 			JOIN jobs j ON j.id = m.job_id
 			JOIN users u ON u.id = m.job_seeker_id
 			LEFT JOIN LATERAL (
 				SELECT content FROM messages 
-				WHERE match_id = m.id 
+				WHERE match_id = m.id AND deleted_at IS NULL
 				ORDER BY created_at DESC LIMIT 1
 			) msg ON true
-			LEFT JOIN LATERAL (
-				SELECT COUNT(*) as cnt FROM messages 
-				WHERE match_id = m.id AND sender_id != $1 AND is_read = false
-			) unread ON true
 			WHERE m.recruiter_id = $1 AND m.status = 'matched'
 			ORDER BY COALESCE(m.last_message_at, m.matched_at) DESC
 		`
@@ -69,13 +70,18 @@ func (s *Server) GetConversations(c *gin.Context) {
 	for rows.Next() {
 		var conv models.Conversation
 		var lastMessage *string
+		var otherUserID uuid.UUID
+		var lastSeenAt *time.Time
+		var hidePresence, connected bool
 
 		if err := rows.Scan(
 			&conv.MatchID, &conv.OtherUserName, &conv.JobTitle, &conv.CompanyName,
 			&conv.UnreadCount, &conv.Status, &conv.UpdatedAt, &lastMessage,
+			&otherUserID, &lastSeenAt, &hidePresence, &connected,
 		); err != nil {
 			continue
 		}
+		conv.OtherUserPresence = s.presenceOf(otherUserID, lastSeenAt, connected, hidePresence)
 
 		if lastMessage != nil {
 			conv.LastMessage = &models.Message{Content: *lastMessage}
@@ -109,19 +115,62 @@ func (s *Server) GetMessages(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > maxMessagesPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxMessagesPage)})
+		return
+	}
 
-	rows, err := s.db.Query(`
+	// Pages are anchored on a message rather than an offset, so messages
+	// arriving meanwhile don't shift them. Without a cursor the latest
+	// messages are returned.
+	before, after := c.Query("before"), c.Query("after")
+	if before != "" && after != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Pass either before or after, not both"})
+		return
+	}
+
+	query := `
 		SELECT m.id, m.match_id, m.sender_id, m.type, m.content, 
-		       m.is_read, m.read_at, m.created_at, u.first_name
+		       m.is_read, m.read_at, m.created_at, m.edited_at, m.deleted_at, u.first_name,
+		       a.id, a.file_name, a.content_type, a.size_bytes
 		FROM messages m
 		JOIN users u ON u.id = m.sender_id
-		WHERE m.match_id = $1
-		ORDER BY m.created_at DESC
-		LIMIT $2 OFFSET $3
-	`, matchID, limit, offset)
+		LEFT JOIN message_attachments a ON a.message_id = m.id
+		WHERE m.match_id = $1`
+	args := []interface{}{matchID, limit + 1}
+	newestFirst := true
+
+	if cursor := before + after; cursor != "" {
+		cursorID, err := uuid.Parse(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message cursor"})
+			return
+		}
+		var cursorExists bool
+		s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM messages WHERE id = $1 AND match_id = $2)`, cursorID, matchID).Scan(&cursorExists)
+		if !cursorExists {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Cursor message not found in this conversation"})
+			return
+		}
+		args = append(args, cursorID)
+
+		cursorSQL := `(SELECT created_at, id FROM messages WHERE id = $3)`
+		if before != "" {
+			query += ` AND (m.created_at, m.id) < ` + cursorSQL
+		} else {
+			query += ` AND (m.created_at, m.id) > ` + cursorSQL
+			newestFirst = false
+		}
+	}
+
+	if newestFirst {
+		query += ` ORDER BY m.created_at DESC, m.id DESC LIMIT $2`
+	} else {
+		query += ` ORDER BY m.created_at, m.id LIMIT $2`
+	}
 
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch messages"})
 		return
@@ -131,24 +180,141 @@ func (s *Server) GetMessages(c *gin.Context) {
 	messages := []models.MessageWithSender{}
 	for rows.Next() {
 		var msg models.MessageWithSender
+		var attachmentID *uuid.UUID
+		var fileName, contentType *string
+		var size *int64
 		if err := rows.Scan(
 			&msg.ID, &msg.MatchID, &msg.SenderID, &msg.Type, &msg.Content,
-			&msg.IsRead, &msg.ReadAt, &msg.CreatedAt, &msg.SenderName,
+			&msg.IsRead, &msg.ReadAt, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt, &msg.SenderName,
+			&attachmentID, &fileName, &contentType, &size,
 		); err != nil {
 			continue
 		}
 		msg.IsMine = msg.SenderID == userID
+		if msg.DeletedAt != nil {
+			// Tombstone: keep its place in the conversation, not its content
+			msg.Content = ""
+		} else if attachmentID != nil {
+			msg.Attachment = &models.Attachment{
+				ID:          *attachmentID,
+				FileName:    *fileName,
+				ContentType: *contentType,
+				Size:        *size,
+				URL:         attachmentURL(matchID, *attachmentID),
+			}
+		}
 		messages = append(messages, msg)
 	}
 
+	// One extra row was fetched to tell whether there is another page
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+
 	// Reverse to show oldest first
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+	if newestFirst {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
 	}
 
+	c.Header("X-Has-More", strconv.FormatBool(hasMore))
 	c.JSON(http.StatusOK, messages)
 }
 
+// Search snippets are highlighted between these control characters, which
+// are stripped from the message first, then HTML-escaped and marked up
+const (
+	snippetStart = "\x02"
+	snippetStop  = "\x03"
+)
+
+// snippetHeadlineSQL highlights the search terms in a message with the
+// snippet markers
+const snippetHeadlineSQL = `ts_headline('english', translate(msg.content, chr(2) || chr(3), ''), tsq,
+	'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2')`
+
+// highlightSnippet escapes a headline for HTML and turns its markers into
+// <mark> tags, so the highlighting is the only markup in a snippet
+func highlightSnippet(headline string) string {
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(html.EscapeString(headline))
+}
+
+// SearchMessages runs a full-text search over every conversation the user
+// is part of, newest first
+func (s *Server) SearchMessages(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > maxMessagesPage {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxMessagesPage)})
+		return
+	}
+
+	query := `
+		SELECT msg.id, msg.match_id, msg.sender_id, u.first_name, j.title,
+		       ` + snippetHeadlineSQL + `,
+		       msg.created_at
+		FROM messages msg
+		JOIN matches m ON m.id = msg.match_id
+		JOIN jobs j ON j.id = m.job_id
+		JOIN users u ON u.id = msg.sender_id,
+		     websearch_to_tsquery('english', $2) tsq
+		WHERE (m.job_seeker_id = $1 OR m.recruiter_id = $1)
+		  AND msg.deleted_at IS NULL
+		  AND to_tsvector('english', msg.content) @@ tsq`
+	args := []interface{}{userID, q, limit + 1}
+
+	if before := c.Query("before"); before != "" {
+		cursorID, err := uuid.Parse(before)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message cursor"})
+			return
+		}
+		query += ` AND (msg.created_at, msg.id) < (SELECT created_at, id FROM messages WHERE id = $4)`
+		args = append(args, cursorID)
+	}
+	query += ` ORDER BY msg.created_at DESC, msg.id DESC LIMIT $3`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search messages"})
+		return
+	}
+	defer rows.Close()
+
+	results := []models.MessageSearchResult{}
+	for rows.Next() {
+		var r models.MessageSearchResult
+		if err := rows.Scan(
+			&r.MessageID, &r.MatchID, &r.SenderID, &r.SenderName, &r.JobTitle,
+			&r.Snippet, &r.CreatedAt,
+		); err != nil {
+			continue
+		}
+		r.IsMine = r.SenderID == userID
+		r.Snippet = highlightSnippet(r.Snippet)
+		results = append(results, r)
+	}
+
+	hasMore := len(results) > limit
+	if hasMore {
+		results = results[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"has_more": hasMore,
+	})
+}
+
 func (s *Server) SendMessage(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 	matchID, err := uuid.Parse(c.Param("match_id"))
@@ -157,95 +323,275 @@ func (s *Server) SendMessage(c *gin.Context) {
 		return
 	}
 
+	// Either content or one of the recruiter's templates, filled in for
+	// this match
 	var req struct {
-		Content string `json:"content" binding:"required"`
+		Content    string     `json:"content"`
+		TemplateID *uuid.UUID `json:"template_id"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if (req.Content == "") == (req.TemplateID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either content or template_id"})
+		return
+	}
 
-	// Verify user is part of this match and get other user
-	var jobSeekerID, recruiterID uuid.UUID
-	err = s.db.QueryRow(`
-		SELECT job_seeker_id, recruiter_id FROM matches 
-		WHERE id = $1 AND status = 'matched' AND (job_seeker_id = $2 OR recruiter_id = $2)
-	`, matchID, userID).Scan(&jobSeekerID, &recruiterID)
+	if req.TemplateID != nil {
+		req.Content, err = s.renderTemplateForMatch(*req.TemplateID, userID, matchID)
+		if err == errTemplateNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		if err == errMatchNotFound {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render template"})
+			return
+		}
+	}
 
+	msg, err := s.sendChatMessage(userID, matchID, req.Content)
+	if err == errMatchNotFound {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, msg)
+}
+
+func (s *Server) MarkMessagesRead(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("match_id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	_, err = s.markMessagesRead(userID, matchID)
+	if err == errMatchNotFound {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read"})
+}
+
+// maxMessagesPage caps how many messages or search results one request
+// returns
+const maxMessagesPage = 100
+
+var errMatchNotFound = errors.New("match not found or access denied")
+
+// chatParticipants returns both sides of a matched conversation the user
+// is part of, and errMatchNotFound otherwise
+func (s *Server) chatParticipants(userID, matchID uuid.UUID) (jobSeekerID, recruiterID uuid.UUID, err error) {
+	return scanChatParticipants(s.db.QueryRow(chatParticipantsSQL, matchID, userID))
+}
+
+// lockConversation is chatParticipants that also locks the match row for
+// the rest of the transaction, so messages and read marks on one
+// conversation are applied one at a time and unread counts stay exact
+func lockConversation(tx *sql.Tx, userID, matchID uuid.UUID) (jobSeekerID, recruiterID uuid.UUID, err error) {
+	return scanChatParticipants(tx.QueryRow(chatParticipantsSQL+` FOR UPDATE`, matchID, userID))
+}
+
+const chatParticipantsSQL = `
+	SELECT job_seeker_id, recruiter_id FROM matches 
+	WHERE id = $1 AND status = 'matched' AND (job_seeker_id = $2 OR recruiter_id = $2)`
+
+func scanChatParticipants(row *sql.Row) (jobSeekerID, recruiterID uuid.UUID, err error) {
+	err = row.Scan(&jobSeekerID, &recruiterID)
+	if err == sql.ErrNoRows {
+		err = errMatchNotFound
+	}
+	return jobSeekerID, recruiterID, err
+}
+
+// refreshUnreadCounts recounts each side's unread messages in a match from
+// messages.is_read, ignoring deleted messages. Run it after anything that
+// adds, deletes or reads messages, with the match row locked.
+func refreshUnreadCounts(q querier, matchID uuid.UUID) error {
+	_, err := q.Exec(`
+		UPDATE matches m SET
+			job_seeker_unread_count = (
+				SELECT COUNT(*) FROM messages
+				WHERE match_id = m.id AND sender_id IS DISTINCT FROM m.job_seeker_id AND is_read = false AND deleted_at IS NULL
+			),
+			recruiter_unread_count = (
+				SELECT COUNT(*) FROM messages
+				WHERE match_id = m.id AND sender_id IS DISTINCT FROM m.recruiter_id AND is_read = false AND deleted_at IS NULL
+			)
+		WHERE m.id = $1
+	`, matchID)
+	return err
+}
+
+// otherParticipant is whichever side of the match the user isn't
+func otherParticipant(userID, jobSeekerID, recruiterID uuid.UUID) uuid.UUID {
+	if userID == jobSeekerID {
+		return recruiterID
+	}
+	return jobSeekerID
+}
+
+// sendChatMessage stores a text message and notifies the recipient. It is
+// shared by the REST endpoint and the WebSocket connection.
+func (s *Server) sendChatMessage(userID, matchID uuid.UUID, content string) (*models.Message, error) {
+	return s.postMessage(userID, matchID, models.MessageTypeText, content, nil)
+}
+
+// postMessage stores a message, with the file it shares if any, and
+// notifies the recipient
+func (s *Server) postMessage(userID, matchID uuid.UUID, msgType models.MessageType, content string, file *storedFile) (*models.Message, error) {
+	var msg *models.Message
+	var recipientID uuid.UUID
+	err := s.withTx(func(tx *sql.Tx) error {
+		// Verify user is part of this match and get other user
+		jobSeekerID, recruiterID, err := lockConversation(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		recipientID = otherParticipant(userID, jobSeekerID, recruiterID)
+
+		msg, err = insertMessage(tx, userID, matchID, msgType, content, file)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The message ends whatever the sender was typing
+	s.relayTyping(userID, recipientID, matchID, false)
 
-	// Insert message
+	s.sendNotifications([]notification{s.messageNotification(recipientID, msg)})
+
+	return msg, nil
+}
+
+// insertMessage adds a message to a conversation locked by the caller and
+// updates the conversation's last message time and unread counts
+func insertMessage(tx *sql.Tx, userID, matchID uuid.UUID, msgType models.MessageType, content string, file *storedFile) (*models.Message, error) {
 	var msg models.Message
-	err = s.db.QueryRow(`
+	err := tx.QueryRow(`
 		INSERT INTO messages (match_id, sender_id, type, content)
-		VALUES ($1, $2, 'text', $3)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, match_id, sender_id, type, content, is_read, created_at
-	`, matchID, userID, req.Content).Scan(
+	`, matchID, userID, msgType, content).Scan(
 		&msg.ID, &msg.MatchID, &msg.SenderID, &msg.Type, &msg.Content, &msg.IsRead, &msg.CreatedAt,
 	)
-
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
-		return
+		return nil, err
 	}
 
-	// Update match's last message time and increment unread count for recipient
-	s.db.Exec(`
-		UPDATE matches SET last_message_at = $1, unread_count = unread_count + 1, updated_at = $1
-		WHERE id = $2
-	`, time.Now(), matchID)
+	if file != nil {
+		msg.Attachment, err = insertAttachment(tx, msg.ID, matchID, userID, file)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// Determine recipient
-	recipientID := jobSeekerID
-	if userID == jobSeekerID {
-		recipientID = recruiterID
+	// Update match's last message time and the recipient's unread count
+	if _, err := tx.Exec(`
+		UPDATE matches SET last_message_at = $1, updated_at = $1 WHERE id = $2
+	`, msg.CreatedAt, matchID); err != nil {
+		return nil, err
 	}
+	if err := refreshUnreadCounts(tx, matchID); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
 
+// messageNotification is the real-time "message" event for a new message
+func (s *Server) messageNotification(recipientID uuid.UUID, msg *models.Message) notification {
 	// Get sender name
 	var senderName string
-	s.db.QueryRow(`SELECT first_name FROM users WHERE id = $1`, userID).Scan(&senderName)
-
-	// Send real-time notification
-	s.hub.SendToUser(recipientID, map[string]interface{}{
-		"type": "message",
-		"payload": map[string]interface{}{
-			"match_id":    matchID.String(),
-			"message_id":  msg.ID.String(),
-			"sender_name": senderName,
-			"content":     req.Content,
-			"created_at":  msg.CreatedAt,
-		},
-	})
+	s.db.QueryRow(`SELECT first_name FROM users WHERE id = $1`, msg.SenderID).Scan(&senderName)
 
-	c.JSON(http.StatusCreated, msg)
+	return newMessageNotification(recipientID, senderName, msg)
 }
 
-func (s *Server) MarkMessagesRead(c *gin.Context) {
-	userID := c.MustGet("user_id").(uuid.UUID)
-	matchID, err := uuid.Parse(c.Param("match_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
-		return
+// newMessageNotification is messageNotification for a sender whose name is
+// already known
+func newMessageNotification(recipientID uuid.UUID, senderName string, msg *models.Message) notification {
+	payload := map[string]interface{}{
+		"match_id":     msg.MatchID.String(),
+		"message_id":   msg.ID.String(),
+		"sender_name":  senderName,
+		"message_type": msg.Type,
+		"content":      msg.Content,
+		"created_at":   msg.CreatedAt,
+	}
+	if msg.Attachment != nil {
+		payload["attachment"] = msg.Attachment
+	}
+	return notification{
+		userID: recipientID,
+		message: map[string]interface{}{
+			"type":    "message",
+			"payload": payload,
+		},
 	}
+}
 
+// markMessagesRead marks the other side's messages in a conversation read
+// and sends them a read receipt with the latest message read
+func (s *Server) markMessagesRead(userID, matchID uuid.UUID) (time.Time, error) {
 	now := time.Now()
 
-	// Mark all messages as read (except own messages)
-	_, err = s.db.Exec(`
-		UPDATE messages SET is_read = true, read_at = $1
-		WHERE match_id = $2 AND sender_id != $3 AND is_read = false
-	`, now, matchID, userID)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark messages read"})
-		return
+	var senderID uuid.UUID
+	var lastReadID *uuid.UUID
+	err := s.withTx(func(tx *sql.Tx) error {
+		jobSeekerID, recruiterID, err := lockConversation(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		senderID = otherParticipant(userID, jobSeekerID, recruiterID)
+
+		// Mark all messages as read (except own messages)
+		err = tx.QueryRow(`
+			WITH marked AS (
+				UPDATE messages SET is_read = true, read_at = $1
+				WHERE match_id = $2 AND sender_id IS DISTINCT FROM $3 AND is_read = false
+				RETURNING id, created_at
+			)
+			SELECT id FROM marked ORDER BY created_at DESC LIMIT 1
+		`, now, matchID, userID).Scan(&lastReadID)
+		if err == sql.ErrNoRows {
+			// Nothing new to read
+			lastReadID = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return refreshUnreadCounts(tx, matchID)
+	})
+	if err != nil || lastReadID == nil {
+		return now, err
 	}
 
-	// Reset unread count
-	s.db.Exec(`UPDATE matches SET unread_count = 0 WHERE id = $1`, matchID)
+	s.hub.SendToUser(senderID, map[string]interface{}{
+		"type": "read_receipt",
+		"payload": models.WSReadReceipt{
+			MatchID:           matchID.String(),
+			ReaderID:          userID.String(),
+			LastReadMessageID: lastReadID.String(),
+			ReadAt:            now,
+		},
+	})
 
-	c.JSON(http.StatusOK, gin.H{"message": "Messages marked as read"})
+	return now, nil
 }
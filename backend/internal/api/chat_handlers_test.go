@@ -0,0 +1,20 @@
+package api
+
+import "testing"
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"see you \x02Monday\x03", "see you <mark>Monday</mark>"},
+		{"<script>alert(1)</script> \x02offer\x03", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>offer</mark>"},
+		{"\x02salary\x03 & <b>bonus</b> \"equity\"", "<mark>salary</mark> &amp; &lt;b&gt;bonus&lt;/b&gt; &#34;equity&#34;"},
+		{"<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+	for _, tt := range tests {
+		if got := highlightSnippet(tt.headline); got != tt.want {
+			t.Errorf("highlightSnippet(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
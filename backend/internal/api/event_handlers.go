@@ -0,0 +1,140 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetEvents returns the user's real-time events after ?since=, for clients
+// that reconnect or can't hold a WebSocket open
+func (s *Server) GetEvents(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since must be a non-negative sequence number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+		return
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	// Fetch one extra to tell whether there is more
+	events, err := s.hub.EventsSince(userID, since, limit+1)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+
+	hasMore := len(events) > limit
+	if hasMore {
+		events = events[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":   events,
+		"has_more": hasMore,
+	})
+}
+
+// StreamEvents delivers the same events as the WebSocket as Server-Sent
+// Events, for clients behind proxies that block WebSocket upgrades
+func (s *Server) StreamEvents(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Don't let nginx buffer the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	client := websocket.NewClient(s.hub, nil, userID, userType)
+	s.hub.Register(client)
+	defer s.hub.Unregister(client)
+
+	s.touchPresence(userID)
+	defer s.touchPresence(userID)
+
+	// EventSource sends Last-Event-ID itself when it reconnects
+	since := c.GetHeader("Last-Event-ID")
+	if since == "" {
+		since = c.Query("since")
+	}
+	if seq, err := strconv.ParseInt(since, 10, 64); err == nil && seq >= 0 {
+		events, err := s.hub.Replay(client, seq, wsReplayLimit)
+		if err != nil {
+			log.Printf("Failed to replay events for %s: %v", userID, err)
+		} else if len(events) == wsReplayLimit {
+			client.SendMessage("replay_incomplete", map[string]interface{}{
+				"since": events[len(events)-1].Seq,
+			})
+		}
+	}
+
+	heartbeat := time.NewTicker(websocket.PingPeriod)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case data, ok := <-client.Send:
+			if !ok {
+				// Dropped by the hub for falling behind
+				return
+			}
+			if err := writeSSE(c.Writer, data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-heartbeat.C:
+			s.touchPresence(userID)
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSE writes a hub message as an SSE event named after its type, with
+// its sequence number as the event ID when it has one
+func writeSSE(w io.Writer, data []byte) error {
+	var msg struct {
+		Seq  int64  `json:"seq"`
+		Type string `json:"type"`
+	}
+	json.Unmarshal(data, &msg)
+
+	var b strings.Builder
+	if msg.Seq > 0 {
+		fmt.Fprintf(&b, "id: %d\n", msg.Seq)
+	}
+	if msg.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", msg.Type)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 	"time"
 
@@ -155,12 +156,16 @@ func (s *Server) ClaimDailyReward(c *gin.Context) {
 	})
 }
 
-func (s *Server) checkAndAwardBadges(userID uuid.UUID) {
+func checkAndAwardBadges(q querier, userID uuid.UUID) error {
 	var totalSwipes, totalMatches int
 	var currentStreak int
 
-	s.db.QueryRow(`SELECT total_swipes, total_matches FROM users WHERE id = $1`, userID).Scan(&totalSwipes, &totalMatches)
-	s.db.QueryRow(`SELECT current_streak FROM daily_streaks WHERE user_id = $1`, userID).Scan(&currentStreak)
+	if err := q.QueryRow(`SELECT COALESCE(total_swipes, 0), COALESCE(total_matches, 0) FROM users WHERE id = $1`, userID).Scan(&totalSwipes, &totalMatches); err != nil {
+		return err
+	}
+	if err := q.QueryRow(`SELECT current_streak FROM daily_streaks WHERE user_id = $1`, userID).Scan(&currentStreak); err != nil && err != sql.ErrNoRows {
+		return err
+	}
 
 	badgesToAward := []models.BadgeType{}
 
@@ -199,18 +204,21 @@ func (s *Server) checkAndAwardBadges(userID uuid.UUID) {
 
 	// Award badges (ignore duplicates)
 	for _, badge := range badgesToAward {
-		s.db.Exec(`
+		if _, err := q.Exec(`
 			INSERT INTO user_badges (user_id, badge_type) VALUES ($1, $2)
 			ON CONFLICT (user_id, badge_type) DO NOTHING
-		`, userID, badge)
+		`, userID, badge); err != nil {
+			return err
+		}
 	}
 
 	// Update user's badge array
-	s.db.Exec(`
+	_, err := q.Exec(`
 		UPDATE users SET badges = (
 			SELECT ARRAY_AGG(badge_type) FROM user_badges WHERE user_id = $1
 		) WHERE id = $1
 	`, userID)
+	return err
 }
 
 func (s *Server) getAchievements(userID uuid.UUID, totalSwipes, totalMatches, currentStreak int) []models.Achievement {
@@ -302,8 +310,3 @@ func min(a, b int) int {
 	}
 	return b
 }
-
-func (s *Server) HandleWebSocket(c *gin.Context) {
-	// WebSocket upgrade handled by gorilla
-	c.JSON(http.StatusOK, gin.H{"message": "WebSocket endpoint"})
-}
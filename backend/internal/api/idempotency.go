@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader lets clients retry a request without applying it twice
+const idempotencyKeyHeader = "Idempotency-Key"
+
+var errIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// idempotentResponse is a stored response replayed for a retried request
+type idempotentResponse struct {
+	status int
+	body   []byte
+}
+
+// hashRequest fingerprints a request body so a key can't be reused for a
+// different request
+func hashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// claimIdempotencyKey reserves key for this request as part of tx. It returns
+// the stored response if the request was already handled. A concurrent
+// request with the same key blocks here until the first one finishes.
+func claimIdempotencyKey(tx *sql.Tx, userID uuid.UUID, scope, key, requestHash string) (*idempotentResponse, error) {
+	// Keys are only honoured for a day
+	if _, err := tx.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND scope = $2 AND key = $3
+		AND created_at < CURRENT_TIMESTAMP - INTERVAL '24 hours'
+	`, userID, scope, key); err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (user_id, scope, key, request_hash)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, scope, key) DO NOTHING
+	`, userID, scope, key, requestHash)
+	if err != nil {
+		return nil, err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 1 {
+		return nil, nil
+	}
+
+	var storedHash string
+	var status sql.NullInt64
+	var body []byte
+	err = tx.QueryRow(`
+		SELECT request_hash, status_code, response FROM idempotency_keys
+		WHERE user_id = $1 AND scope = $2 AND key = $3
+	`, userID, scope, key).Scan(&storedHash, &status, &body)
+	if err != nil {
+		return nil, err
+	}
+	if storedHash != requestHash || !status.Valid {
+		return nil, errIdempotencyKeyReused
+	}
+
+	return &idempotentResponse{status: int(status.Int64), body: body}, nil
+}
+
+// saveIdempotentResponse stores the response for a key claimed in the same
+// transaction
+func saveIdempotentResponse(tx *sql.Tx, userID uuid.UUID, scope, key string, status int, body []byte) error {
+	_, err := tx.Exec(`
+		UPDATE idempotency_keys SET status_code = $1, response = $2
+		WHERE user_id = $3 AND scope = $4 AND key = $5
+	`, status, body, userID, scope, key)
+	return err
+}
@@ -25,55 +25,63 @@ func (s *Server) ScheduleInterview(c *gin.Context) {
 		return
 	}
 
-	// Verify match ownership
-	var matchExists bool
-	var jobSeekerID uuid.UUID
-	var jobTitle string
-	err := s.db.QueryRow(`
-		SELECT m.job_seeker_id, j.title FROM matches m
-		JOIN jobs j ON j.id = m.job_id
-		WHERE m.id = $1 AND m.recruiter_id = $2 AND m.status = 'matched'
-	`, req.MatchID, userID).Scan(&jobSeekerID, &jobTitle)
+	// Verify match ownership, create the interview and move the application
+	// to the interview stage together
+	var m *pipelineMatch
+	var interview models.Interview
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		m, err = lockRecruiterMatch(tx, userID, req.MatchID)
+		if err != nil {
+			return err
+		}
+		// Further interviews don't change the status
+		if m.Status != models.ApplicationStatusInterview {
+			if err := changeStatus(tx, userID, m, models.ApplicationStatusInterview, "Interview scheduled"); err != nil {
+				return err
+			}
+		}
 
-	if err == sql.ErrNoRows {
+		err = tx.QueryRow(`
+			INSERT INTO interviews (match_id, scheduled_at, duration, type, location, instructions)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, match_id, scheduled_at, duration, type, location, instructions, status, created_at
+		`, req.MatchID, req.ScheduledAt, req.Duration, req.Type, req.Location, req.Instructions).Scan(
+			&interview.ID, &interview.MatchID, &interview.ScheduledAt, &interview.Duration,
+			&interview.Type, &interview.Location, &interview.Instructions, &interview.Status, &interview.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE matches SET interview_status = 'scheduled', updated_at = $1 WHERE id = $2
+		`, time.Now(), req.MatchID); err != nil {
+			return err
+		}
+
+		// Create system message
+		if _, err := tx.Exec(`
+			INSERT INTO messages (match_id, sender_id, type, content)
+			VALUES ($1, $2, 'interview', $3)
+		`, req.MatchID, userID, formatInterviewMessage(interview)); err != nil {
+			return err
+		}
+		return refreshUnreadCounts(tx, req.MatchID)
+	})
+	if err == errMatchNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
 		return
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify match"})
+	if err == errInvalidTransition {
+		respondInvalidTransition(c, m.Status, models.ApplicationStatusInterview)
 		return
 	}
-	matchExists = true
-	_ = matchExists
-
-	// Create interview
-	var interview models.Interview
-	err = s.db.QueryRow(`
-		INSERT INTO interviews (match_id, scheduled_at, duration, type, location, instructions)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, match_id, scheduled_at, duration, type, location, instructions, status, created_at
-	`, req.MatchID, req.ScheduledAt, req.Duration, req.Type, req.Location, req.Instructions).Scan(
-		&interview.ID, &interview.MatchID, &interview.ScheduledAt, &interview.Duration,
-		&interview.Type, &interview.Location, &interview.Instructions, &interview.Status, &interview.CreatedAt,
-	)
-
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule interview"})
 		return
 	}
-
-	// Update match status
-	s.db.Exec(`
-		UPDATE matches SET application_status = 'interview', interview_status = 'scheduled', updated_at = $1
-		WHERE id = $2
-	`, time.Now(), req.MatchID)
-
-	// Create system message
-	interviewMessage := formatInterviewMessage(interview)
-	s.db.Exec(`
-		INSERT INTO messages (match_id, sender_id, type, content)
-		VALUES ($1, $2, 'interview', $3)
-	`, req.MatchID, userID, interviewMessage)
+	jobSeekerID, jobTitle := m.JobSeekerID, m.JobTitle
 
 	// Send notification to job seeker
 	s.hub.SendToUser(jobSeekerID, map[string]interface{}{
@@ -315,38 +323,61 @@ func (s *Server) RecordInterviewResult(c *gin.Context) {
 		return
 	}
 
+	// Update match status based on result
+	newStatus := models.ApplicationStatusRejected
+	if req.Result == "pass" {
+		newStatus = models.ApplicationStatusOffered
+	}
+
 	// Verify ownership and update
-	var matchID, jobSeekerID uuid.UUID
-	err = s.db.QueryRow(`
-		SELECT i.match_id, m.job_seeker_id FROM interviews i
-		JOIN matches m ON m.id = i.match_id
-		WHERE i.id = $1 AND m.recruiter_id = $2
-	`, interviewID, userID).Scan(&matchID, &jobSeekerID)
+	var jobSeekerID uuid.UUID
+	err = s.withTx(func(tx *sql.Tx) error {
+		var matchID uuid.UUID
+		err := tx.QueryRow(`
+			SELECT i.match_id FROM interviews i
+			JOIN matches m ON m.id = i.match_id
+			WHERE i.id = $1 AND m.recruiter_id = $2
+		`, interviewID, userID).Scan(&matchID)
+		if err == sql.ErrNoRows {
+			return errMatchNotFound
+		}
+		if err != nil {
+			return err
+		}
+		m, err := lockRecruiterMatch(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		jobSeekerID = m.JobSeekerID
 
-	if err == sql.ErrNoRows {
+		if _, err := tx.Exec(`
+			UPDATE interviews SET status = 'completed', result = $1, feedback = $2, updated_at = $3
+			WHERE id = $4
+		`, req.Result, req.Feedback, time.Now(), interviewID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			UPDATE matches SET interview_status = 'completed', updated_at = $1 WHERE id = $2
+		`, time.Now(), matchID); err != nil {
+			return err
+		}
+
+		// The result still counts if the application has moved on, e.g.
+		// the candidate withdrew meanwhile
+		if m.Status.CanTransitionTo(newStatus) {
+			return changeStatus(tx, userID, m, newStatus, "Interview result: "+req.Result)
+		}
+		return nil
+	})
+	if err == errMatchNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Interview not found"})
 		return
 	}
-
-	// Update interview
-	s.db.Exec(`
-		UPDATE interviews SET status = 'completed', result = $1, feedback = $2, updated_at = $3
-		WHERE id = $4
-	`, req.Result, req.Feedback, time.Now(), interviewID)
-
-	// Update match status based on result
-	newStatus := models.ApplicationStatusActive
-	if req.Result == "pass" {
-		newStatus = models.ApplicationStatusOffered
-	} else {
-		newStatus = models.ApplicationStatusRejected
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record interview result"})
+		return
 	}
 
-	s.db.Exec(`
-		UPDATE matches SET application_status = $1, interview_status = 'completed', updated_at = $2
-		WHERE id = $3
-	`, newStatus, time.Now(), matchID)
-
 	// Notify job seeker
 	message := "Interview results are in!"
 	if req.Result == "pass" {
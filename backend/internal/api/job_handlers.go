@@ -4,9 +4,12 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/blowjobs-ai/backend/internal/matching"
 	"github.com/blowjobs-ai/backend/internal/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -274,25 +277,77 @@ func (s *Server) GetJobFeed(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if limit > 50 {
-		limit = 50
+	// Profile used to score and filter each job (missing profile leaves scores at 0)
+	profile, _ := s.loadScoringProfile(userID)
+
+	opts, err := parseFeedOptions(c, "min_salary")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Hard filters default to the seeker's own preferences
+	workPreference := opts.workPreference
+	salaryFloor := opts.salary
+	if profile != nil {
+		if workPreference == "" {
+			workPreference = profile.WorkPreference
+		}
+		if salaryFloor < 0 {
+			salaryFloor = profile.ExpectedSalaryMin
+		}
+	}
+
+	conditions := []string{
+		"j.status = 'active'",
+		"j.id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND swipe_type = 'job')",
+		notBlockedSQL("$1", "j.recruiter_id"),
+	}
+	args := []interface{}{userID}
+
+	if excluded := matching.IncompatibleWorkPreference(workPreference); excluded != "" {
+		args = append(args, excluded)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(j.work_preference, 'any') != $%d", len(args)))
+	}
+	if salaryFloor > 0 {
+		// Jobs that don't disclose a salary stay in the feed
+		args = append(args, salaryFloor)
+		conditions = append(conditions, fmt.Sprintf("(COALESCE(j.salary_max, 0) = 0 OR j.salary_max >= $%d)", len(args)))
+	}
+	if profile != nil && opts.experienceTolerance >= 0 {
+		args = append(args, profile.YearsOfExperience, opts.experienceTolerance)
+		years, tolerance := len(args)-1, len(args)
+		conditions = append(conditions, fmt.Sprintf(
+			"COALESCE(j.min_years_exp, 0) <= $%d::int + $%d::int AND (COALESCE(j.max_years_exp, 0) = 0 OR j.max_years_exp + $%d::int >= $%d::int)",
+			years, tolerance, tolerance, years,
+		))
 	}
 
-	// Get jobs that user hasn't swiped on yet
+	// Pull a pool of the best-fitting unseen jobs by skills, then rank it by
+	// match score
+	var skills []string
+	if profile != nil {
+		skills = profile.Skills
+	}
+	args = append(args, pq.StringArray(skills))
+	skillFit := skillFitSQL("j.skills", fmt.Sprintf("$%d::text[]", len(args)))
+	args = append(args, feedPoolSize)
 	rows, err := s.db.Query(`
 		SELECT j.id, j.title, j.company_name, j.location, j.work_preference,
 		       j.job_type, j.experience_level, j.skills, j.salary_min, j.salary_max,
-		       j.salary_currency, j.show_salary, j.benefits, j.is_featured
+		       j.salary_currency, j.show_salary, j.benefits, j.is_featured,
+		       COALESCE(j.min_years_exp, 0), COALESCE(j.max_years_exp, 0),
+		       EXISTS(
+		           SELECT 1 FROM swipes sl
+		           JOIN job_seeker_profiles sp ON sp.id = sl.swiped_id
+		           WHERE sp.user_id = $1 AND sl.swiper_id = j.recruiter_id
+		           AND sl.swipe_type = 'profile' AND sl.direction = 'up'
+		           AND (sl.job_id = j.id OR sl.job_id IS NULL)
+		       ) AS super_liked_you
 		FROM jobs j
-		WHERE j.status = 'active'
-		AND j.id NOT IN (
-			SELECT swiped_id FROM swipes 
-			WHERE swiper_id = $1 AND swipe_type = 'job'
-		)
-		ORDER BY j.is_featured DESC, j.created_at DESC
-		LIMIT $2
-	`, userID, limit)
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY super_liked_you DESC, `+skillFit+` DESC, j.is_featured DESC, j.created_at DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job feed"})
@@ -306,12 +361,14 @@ func (s *Server) GetJobFeed(c *gin.Context) {
 		var salaryMin, salaryMax int
 		var salaryCurrency string
 		var showSalary bool
+		var minYears, maxYears int
 
 		if err := rows.Scan(
 			&card.ID, &card.Title, &card.CompanyName, &card.Location,
 			&card.WorkPreference, &card.JobType, &card.ExperienceLevel,
 			pq.Array(&card.Skills), &salaryMin, &salaryMax, &salaryCurrency,
 			&showSalary, pq.Array(&card.Benefits), &card.IsFeatured,
+			&minYears, &maxYears, &card.SuperLikedYou,
 		); err != nil {
 			continue
 		}
@@ -320,9 +377,41 @@ func (s *Server) GetJobFeed(c *gin.Context) {
 			card.SalaryRange = fmt.Sprintf("%s %dk - %dk", salaryCurrency, salaryMin/1000, salaryMax/1000)
 		}
 
+		if profile != nil {
+			card.Explanation = matching.Explain(profile, &models.Job{
+				ID:              card.ID,
+				Skills:          card.Skills,
+				ExperienceLevel: card.ExperienceLevel,
+				MinYearsExp:     minYears,
+				MaxYearsExp:     maxYears,
+				WorkPreference:  card.WorkPreference,
+				Location:        card.Location,
+				SalaryMin:       salaryMin,
+				SalaryMax:       salaryMax,
+				SalaryCurrency:  salaryCurrency,
+			})
+			card.MatchScore = card.Explanation.Score
+		}
+
+		if card.MatchScore < opts.minScore {
+			continue
+		}
+
 		cards = append(cards, card)
 	}
 
+	// Super likes first, then best fit; the pool order (featured, newest)
+	// breaks ties
+	sort.SliceStable(cards, func(i, j int) bool {
+		if cards[i].SuperLikedYou != cards[j].SuperLikedYou {
+			return cards[i].SuperLikedYou
+		}
+		return cards[i].MatchScore > cards[j].MatchScore
+	})
+	if len(cards) > opts.limit {
+		cards = cards[:opts.limit]
+	}
+
 	c.JSON(http.StatusOK, cards)
 }
 
@@ -335,13 +424,101 @@ func (s *Server) GetCandidateFeed(c *gin.Context) {
 		return
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if limit > 50 {
-		limit = 50
+	opts, err := parseFeedOptions(c, "max_salary")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Candidates are ranked against the chosen job, or against the
+	// recruiter's best-fitting active job when none is given
+	jobs, _ := s.loadScoringJobs(userID)
+	var targetJob *models.Job
+	if raw := c.Query("job_id"); raw != "" {
+		jobID, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+			return
+		}
+		for i := range jobs {
+			if jobs[i].ID == jobID {
+				targetJob = &jobs[i]
+				break
+			}
+		}
+		if targetJob == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found or not active"})
+			return
+		}
+	}
+
+	// Hard filters default to the target job's requirements
+	workPreference := opts.workPreference
+	salaryCeiling := opts.salary
+	if targetJob != nil {
+		if workPreference == "" {
+			workPreference = targetJob.WorkPreference
+		}
+		if salaryCeiling < 0 {
+			salaryCeiling = targetJob.SalaryMax
+		}
+	}
+
+	conditions := []string{
+		"u.is_active = true",
+		"u.user_type = 'job_seeker'",
+		notBlockedSQL("$1", "p.user_id"),
+	}
+	args := []interface{}{userID}
+
+	// Candidates who super-liked the target job, or any of the recruiter's jobs
+	superLikedJobs := "SELECT id FROM jobs WHERE recruiter_id = $1"
+
+	if targetJob != nil {
+		// Candidates already decided on for another job are still fair game
+		args = append(args, targetJob.ID)
+		superLikedJobs = fmt.Sprintf("$%d", len(args))
+		conditions = append(conditions, fmt.Sprintf(
+			"p.id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND swipe_type = 'profile' AND (job_id = $%d OR job_id IS NULL))",
+			len(args),
+		))
+	} else {
+		conditions = append(conditions, "p.id NOT IN (SELECT swiped_id FROM swipes WHERE swiper_id = $1 AND swipe_type = 'profile')")
+	}
+
+	if excluded := matching.IncompatibleWorkPreference(workPreference); excluded != "" {
+		args = append(args, excluded)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(p.work_preference, 'any') != $%d", len(args)))
+	}
+	if salaryCeiling > 0 {
+		// Candidates without salary expectations stay in the feed
+		args = append(args, salaryCeiling)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(p.expected_salary_min, 0) <= $%d", len(args)))
+	}
+	if targetJob != nil && opts.experienceTolerance >= 0 {
+		args = append(args, targetJob.MinYearsExp, targetJob.MaxYearsExp, opts.experienceTolerance)
+		minYears, maxYears, tolerance := len(args)-2, len(args)-1, len(args)
+		conditions = append(conditions, fmt.Sprintf(
+			"COALESCE(p.years_of_experience, 0) + $%d::int >= $%d::int AND ($%d::int = 0 OR COALESCE(p.years_of_experience, 0) <= $%d::int + $%d::int)",
+			tolerance, minYears, maxYears, maxYears, tolerance,
+		))
 	}
 
-	// Get job seeker profiles that recruiter hasn't swiped on
+	// Pull a pool of the best-fitting unseen candidates by skills, then rank
+	// it by match score. Without a target job, candidates are measured
+	// against the skills of all the recruiter's jobs.
 	// Note: Removed is_profile_complete requirement to show more candidates during development
+	var skills []string
+	if targetJob != nil {
+		skills = targetJob.Skills
+	} else {
+		for _, j := range jobs {
+			skills = append(skills, j.Skills...)
+		}
+	}
+	args = append(args, pq.StringArray(skills))
+	skillFit := skillFitSQL(fmt.Sprintf("$%d::text[]", len(args)), "p.skills")
+	args = append(args, feedPoolSize)
 	rows, err := s.db.Query(`
 		SELECT p.id, u.first_name, COALESCE(p.headline, ''), COALESCE(p.summary, ''),
 		       COALESCE(p.experience_level, 'mid'), COALESCE(p.years_of_experience, 0), 
@@ -351,18 +528,17 @@ func (s *Server) GetCandidateFeed(c *gin.Context) {
 		       COALESCE(p.salary_currency, 'USD'), 
 		       COALESCE(p.languages, ARRAY[]::text[]), 
 		       COALESCE(p.certifications, ARRAY[]::text[]), 
-		       COALESCE(p.open_to_relocation, false)
+		       COALESCE(p.open_to_relocation, false),
+		       EXISTS(
+		           SELECT 1 FROM swipes sl
+		           WHERE sl.swiper_id = p.user_id AND sl.swipe_type = 'job' AND sl.direction = 'up'
+		           AND sl.swiped_id IN (`+superLikedJobs+`)
+		       ) AS super_liked_you
 		FROM job_seeker_profiles p
 		JOIN users u ON u.id = p.user_id
-		WHERE u.is_active = true
-		AND u.user_type = 'job_seeker'
-		AND p.id NOT IN (
-			SELECT swiped_id FROM swipes 
-			WHERE swiper_id = $1 AND swipe_type = 'profile'
-		)
-		ORDER BY p.updated_at DESC, p.created_at DESC
-		LIMIT $2
-	`, userID, limit)
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY super_liked_you DESC, `+skillFit+` DESC, p.updated_at DESC, p.created_at DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch candidate feed"})
@@ -382,7 +558,7 @@ func (s *Server) GetCandidateFeed(c *gin.Context) {
 			&card.ExperienceLevel, &card.YearsOfExperience, pq.Array(&card.Skills),
 			&card.WorkPreference, pq.Array(&locations), &salaryMin, &salaryMax,
 			&salaryCurrency, pq.Array(&card.Languages), pq.Array(&card.Certifications),
-			&card.OpenToRelocation,
+			&card.OpenToRelocation, &card.SuperLikedYou,
 		); err != nil {
 			continue
 		}
@@ -394,9 +570,45 @@ func (s *Server) GetCandidateFeed(c *gin.Context) {
 			card.ExpectedSalary = fmt.Sprintf("%s %dk - %dk", salaryCurrency, salaryMin/1000, salaryMax/1000)
 		}
 
+		profile := &models.JobSeekerProfile{
+			Skills:             card.Skills,
+			ExperienceLevel:    card.ExperienceLevel,
+			YearsOfExperience:  card.YearsOfExperience,
+			PreferredLocations: locations,
+			WorkPreference:     card.WorkPreference,
+			ExpectedSalaryMin:  salaryMin,
+			ExpectedSalaryMax:  salaryMax,
+			SalaryCurrency:     salaryCurrency,
+			OpenToRelocation:   card.OpenToRelocation,
+		}
+		if targetJob != nil {
+			card.Explanation = matching.Explain(profile, targetJob)
+		} else {
+			card.Explanation = matching.BestExplain(profile, jobs)
+		}
+		if card.Explanation != nil {
+			card.MatchScore = card.Explanation.Score
+		}
+
+		if card.MatchScore < opts.minScore {
+			continue
+		}
+
 		cards = append(cards, card)
 	}
 
+	// Super likes first, then best fit; the pool order (recently updated)
+	// breaks ties
+	sort.SliceStable(cards, func(i, j int) bool {
+		if cards[i].SuperLikedYou != cards[j].SuperLikedYou {
+			return cards[i].SuperLikedYou
+		}
+		return cards[i].MatchScore > cards[j].MatchScore
+	})
+	if len(cards) > opts.limit {
+		cards = cards[:opts.limit]
+	}
+
 	c.JSON(http.StatusOK, cards)
 }
 
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// TestJobFeedSurfacesOlderStrongFit buries a month-old job that fits the
+// seeker perfectly under more than a pool's worth of newer jobs that don't.
+// The feed must still find it.
+func TestJobFeedSurfacesOlderStrongFit(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	seeker := createTestUser(t, s, "job_seeker")
+	recruiter := createTestUser(t, s, "recruiter")
+
+	// Skills unique to this run, so other rows in the database can't match
+	skill := "skill-" + uuid.NewString()
+	if _, err := s.db.Exec(`
+		INSERT INTO job_seeker_profiles (user_id, skills) VALUES ($1, ARRAY[$2])
+	`, seeker.id, skill); err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO recruiter_profiles (user_id, company_name) VALUES ($1, 'Acme')
+	`, recruiter.id); err != nil {
+		t.Fatalf("create recruiter profile: %v", err)
+	}
+
+	var strongID uuid.UUID
+	if err := s.db.QueryRow(`
+		INSERT INTO jobs (recruiter_id, title, description, job_type, company_name, skills, created_at)
+		VALUES ($1, 'Engineer', 'Builds things', 'full_time', 'Acme', ARRAY[$2], NOW() - INTERVAL '30 days')
+		RETURNING id
+	`, recruiter.id, skill).Scan(&strongID); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO jobs (recruiter_id, title, description, job_type, company_name, skills)
+		SELECT $1, 'Filler ' || n, 'Builds other things', 'full_time', 'Acme', ARRAY['filler-' || n]
+		FROM generate_series(1, $2::int) n
+	`, recruiter.id, feedPoolSize+10); err != nil {
+		t.Fatalf("create filler jobs: %v", err)
+	}
+
+	w := seeker.do(s, http.MethodGet, "/api/v1/jobs/feed?limit=50&min_score=100", nil, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("feed returned %d: %s", w.Code, w.Body.String())
+	}
+	var cards []models.JobCard
+	if err := json.Unmarshal(w.Body.Bytes(), &cards); err != nil {
+		t.Fatalf("bad feed %s: %v", w.Body.String(), err)
+	}
+	for _, card := range cards {
+		if card.ID == strongID {
+			return
+		}
+	}
+	t.Errorf("older job with every skill missing from a feed of %d cards", len(cards))
+}
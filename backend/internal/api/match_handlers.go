@@ -2,6 +2,9 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -19,29 +22,35 @@ func (s *Server) GetMatches(c *gin.Context) {
 		query = `
 			SELECT m.id, m.job_id, m.job_seeker_id, m.recruiter_id, m.status,
 			       m.application_status, m.interview_status, m.matched_at,
-			       m.last_message_at, m.unread_count,
+			       m.last_message_at, COALESCE(m.job_seeker_unread_count, 0), COALESCE(m.is_super_like, false),
 			       j.title, j.company_name, u.first_name as recruiter_name
 			FROM matches m
 			JOIN jobs j ON j.id = m.job_id
 			JOIN users u ON u.id = m.recruiter_id
-			WHERE m.job_seeker_id = $1 AND m.status = 'matched'
+			WHERE m.job_seeker_id = $1 AND m.status = $2
 			ORDER BY COALESCE(m.last_message_at, m.matched_at) DESC
 		`
 	} else {
 		query = `
 			SELECT m.id, m.job_id, m.job_seeker_id, m.recruiter_id, m.status,
 			       m.application_status, m.interview_status, m.matched_at,
-			       m.last_message_at, m.unread_count,
+			       m.last_message_at, COALESCE(m.recruiter_unread_count, 0), COALESCE(m.is_super_like, false),
 			       j.title, j.company_name, u.first_name as job_seeker_name
 			FROM matches m
 			JOIN jobs j ON j.id = m.job_id
 			JOIN users u ON u.id = m.job_seeker_id
-			WHERE m.recruiter_id = $1 AND m.status = 'matched'
+			WHERE m.recruiter_id = $1 AND m.status = $2
 			ORDER BY COALESCE(m.last_message_at, m.matched_at) DESC
 		`
 	}
 
-	rows, err := s.db.Query(query, userID)
+	// Expired matches are archived, and only listed on request
+	status := models.MatchStatusMatched
+	if c.Query("archived") == "true" {
+		status = models.MatchStatusExpired
+	}
+
+	rows, err := s.db.Query(query, userID, status)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch matches"})
 		return
@@ -56,7 +65,7 @@ func (s *Server) GetMatches(c *gin.Context) {
 		if err := rows.Scan(
 			&m.ID, &m.JobID, &m.JobSeekerID, &m.RecruiterID, &m.Status,
 			&m.ApplicationStatus, &m.InterviewStatus, &m.MatchedAt,
-			&m.LastMessageAt, &m.UnreadCount,
+			&m.LastMessageAt, &m.UnreadCount, &m.IsSuperLike,
 			&jobTitle, &companyName, &otherName,
 		); err != nil {
 			continue
@@ -78,6 +87,8 @@ func (s *Server) GetMatches(c *gin.Context) {
 		matches = append(matches, m)
 	}
 
+	s.explainMatches(matches)
+
 	c.JSON(http.StatusOK, matches)
 }
 
@@ -91,24 +102,33 @@ func (s *Server) GetMatch(c *gin.Context) {
 
 	var m models.MatchWithDetails
 	var jobTitle, companyName, jobSeekerName, recruiterName string
+	var lastSeenAt *time.Time
+	var hidePresence, connected bool
 
+	// Presence columns are the counterpart's, not the caller's
 	err = s.db.QueryRow(`
 		SELECT m.id, m.job_id, m.job_seeker_id, m.recruiter_id, m.status,
 		       m.application_status, m.interview_status, m.matched_at,
-		       m.last_message_at, m.unread_count,
+		       m.last_message_at,
+		       COALESCE(CASE WHEN m.job_seeker_id = $2 THEN m.job_seeker_unread_count ELSE m.recruiter_unread_count END, 0),
+		       COALESCE(m.is_super_like, false),
 		       j.title, j.company_name,
 		       js.first_name as job_seeker_name,
-		       r.first_name as recruiter_name
+		       r.first_name as recruiter_name,
+		       other.last_seen_at, COALESCE(other.hide_presence, false),
+		       `+onlineSQL("other")+`
 		FROM matches m
 		JOIN jobs j ON j.id = m.job_id
 		JOIN users js ON js.id = m.job_seeker_id
 		JOIN users r ON r.id = m.recruiter_id
+		JOIN users other ON other.id = CASE WHEN m.job_seeker_id = $2 THEN m.recruiter_id ELSE m.job_seeker_id END
 		WHERE m.id = $1 AND (m.job_seeker_id = $2 OR m.recruiter_id = $2)
 	`, matchID, userID).Scan(
 		&m.ID, &m.JobID, &m.JobSeekerID, &m.RecruiterID, &m.Status,
 		&m.ApplicationStatus, &m.InterviewStatus, &m.MatchedAt,
-		&m.LastMessageAt, &m.UnreadCount,
+		&m.LastMessageAt, &m.UnreadCount, &m.IsSuperLike,
 		&jobTitle, &companyName, &jobSeekerName, &recruiterName,
+		&lastSeenAt, &hidePresence, &connected,
 	)
 
 	if err == sql.ErrNoRows {
@@ -124,8 +144,12 @@ func (s *Server) GetMatch(c *gin.Context) {
 	m.CompanyName = companyName
 	m.JobSeekerName = jobSeekerName
 	m.RecruiterName = recruiterName
+	m.CounterpartPresence = s.presenceOf(otherParticipant(userID, m.JobSeekerID, m.RecruiterID), lastSeenAt, connected, hidePresence)
 
-	c.JSON(http.StatusOK, m)
+	details := []models.MatchWithDetails{m}
+	s.explainMatches(details)
+
+	c.JSON(http.StatusOK, details[0])
 }
 
 func (s *Server) UpdateMatchStatus(c *gin.Context) {
@@ -144,59 +168,188 @@ func (s *Server) UpdateMatchStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		ApplicationStatus models.ApplicationStatus `json:"application_status"`
+		ApplicationStatus models.ApplicationStatus `json:"application_status" binding:"required"`
+		Reason            string                   `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !req.ApplicationStatus.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application status"})
+		return
+	}
+	if msg := recruiterStatusError(req.ApplicationStatus); msg != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": msg})
+		return
+	}
 
 	// Verify ownership and update
-	result, err := s.db.Exec(`
-		UPDATE matches SET application_status = $1, updated_at = $2
-		WHERE id = $3 AND recruiter_id = $4
-	`, req.ApplicationStatus, time.Now(), matchID, userID)
-
+	var m *pipelineMatch
+	var notifications []notification
+	err = s.withTx(func(tx *sql.Tx) error {
+		var err error
+		m, err = lockRecruiterMatch(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		notifications, err = s.applyStatusChange(tx, userID, m, req.ApplicationStatus, req.Reason)
+		return err
+	})
+	if err == errMatchNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+	if err == errInvalidTransition {
+		respondInvalidTransition(c, m.Status, req.ApplicationStatus)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
-		return
+	s.sendNotifications(notifications)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully"})
+}
+
+// recruiterStatusError is why a recruiter can't move a match to status
+// themselves, or "" if they can. Only the candidate withdraws, and a hire
+// only happens by the candidate accepting an offer.
+func recruiterStatusError(status models.ApplicationStatus) string {
+	switch status {
+	case models.ApplicationStatusWithdrawn:
+		return "Only the candidate can withdraw an application"
+	case models.ApplicationStatusHired:
+		return "A candidate is hired by accepting an offer"
 	}
+	return ""
+}
 
-	// Get job seeker ID to send notification
-	var jobSeekerID uuid.UUID
-	var jobTitle string
-	s.db.QueryRow(`
-		SELECT m.job_seeker_id, j.title FROM matches m
+// errInvalidTransition is returned for a status change the application
+// state machine doesn't allow
+var errInvalidTransition = errors.New("application status transition not allowed")
+
+// pipelineMatch is a match locked for a status change
+type pipelineMatch struct {
+	ID          uuid.UUID
+	JobSeekerID uuid.UUID
+	RecruiterID uuid.UUID
+	JobTitle    string
+	Status      models.ApplicationStatus
+}
+
+// lockRecruiterMatch locks one of the recruiter's active matches for the
+// rest of the transaction, or returns errMatchNotFound
+func lockRecruiterMatch(tx *sql.Tx, recruiterID, matchID uuid.UUID) (*pipelineMatch, error) {
+	m := &pipelineMatch{ID: matchID, RecruiterID: recruiterID}
+	err := tx.QueryRow(`
+		SELECT m.job_seeker_id, m.application_status, j.title
+		FROM matches m
 		JOIN jobs j ON j.id = m.job_id
-		WHERE m.id = $1
-	`, matchID).Scan(&jobSeekerID, &jobTitle)
+		WHERE m.id = $1 AND m.recruiter_id = $2 AND m.status = 'matched'
+		FOR UPDATE OF m
+	`, matchID, recruiterID).Scan(&m.JobSeekerID, &m.Status, &m.JobTitle)
+	if err == sql.ErrNoRows {
+		return nil, errMatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
 
-	// Send status update notification
-	s.hub.SendToUser(jobSeekerID, map[string]interface{}{
-		"type": "status_update",
-		"payload": map[string]interface{}{
-			"match_id":   matchID.String(),
-			"job_title":  jobTitle,
-			"new_status": req.ApplicationStatus,
-		},
-	})
+// changeStatus moves a locked match to a new application status if the
+// state machine allows it, and records the change in its history. actorID
+// is uuid.Nil for changes the system makes, such as an offer expiring.
+func changeStatus(q querier, actorID uuid.UUID, m *pipelineMatch, status models.ApplicationStatus, reason string) error {
+	if !m.Status.CanTransitionTo(status) {
+		return errInvalidTransition
+	}
+	if _, err := q.Exec(`
+		UPDATE matches SET application_status = $1, updated_at = $2 WHERE id = $3
+	`, status, time.Now(), m.ID); err != nil {
+		return err
+	}
+
+	var changedBy *uuid.UUID
+	if actorID != uuid.Nil {
+		changedBy = &actorID
+	}
+	if _, err := q.Exec(`
+		INSERT INTO application_status_history (match_id, from_status, to_status, changed_by, reason)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+	`, m.ID, m.Status, status, changedBy, reason); err != nil {
+		return err
+	}
+
+	// Close an offer still open when the match moves on without an answer
+	// to it, e.g. the candidate withdrew or the recruiter rejected them
+	if m.Status == models.ApplicationStatusOffered {
+		outcome := models.OfferStatusRescinded
+		switch {
+		case status == models.ApplicationStatusHired:
+			outcome = models.OfferStatusAccepted
+		case actorID == m.JobSeekerID:
+			outcome = models.OfferStatusDeclined
+		}
+		if _, err := q.Exec(`
+			UPDATE offers SET status = $1, updated_at = $2
+			WHERE match_id = $3 AND status IN ('pending', 'countered')
+		`, outcome, time.Now(), m.ID); err != nil {
+			return err
+		}
+	}
+
+	// hired is final, so each hire is counted once
+	if status == models.ApplicationStatusHired {
+		if _, err := q.Exec(`
+			UPDATE recruiter_profiles SET total_hires = total_hires + 1, updated_at = $1 WHERE user_id = $2
+		`, time.Now(), m.RecruiterID); err != nil {
+			return err
+		}
+	}
+
+	m.Status = status
+	return nil
+}
+
+// applyStatusChange moves a locked match to a new application status and
+// posts the status message in its conversation. The job seeker's
+// notification is returned to send once the transaction commits.
+func (s *Server) applyStatusChange(tx *sql.Tx, actorID uuid.UUID, m *pipelineMatch, status models.ApplicationStatus, reason string) ([]notification, error) {
+	if err := changeStatus(tx, actorID, m, status, reason); err != nil {
+		return nil, err
+	}
 
 	// Create system message about status change
-	statusMessage := getStatusMessage(req.ApplicationStatus)
-	s.db.Exec(`
-		INSERT INTO messages (match_id, sender_id, type, content)
-		VALUES ($1, $2, 'status', $3)
-	`, matchID, userID, statusMessage)
+	content, err := statusMessage(tx, m.RecruiterID, m.ID, status)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := insertMessage(tx, actorID, m.ID, models.MessageTypeStatus, content, nil); err != nil {
+		return nil, err
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully"})
+	// Send status update notification
+	return []notification{{
+		userID: m.JobSeekerID,
+		message: map[string]interface{}{
+			"type": "status_update",
+			"payload": map[string]interface{}{
+				"match_id":   m.ID.String(),
+				"job_title":  m.JobTitle,
+				"new_status": status,
+			},
+		},
+	}}, nil
 }
 
+// UnmatchMatch ends a match, with an optional reason. Future interviews are
+// cancelled, an open offer is rescinded and the other side gets an
+// unmatched event. With block, the two never see each other in their feeds
+// or match again.
 func (s *Server) UnmatchMatch(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 	matchID, err := uuid.Parse(c.Param("id"))
@@ -205,23 +358,139 @@ func (s *Server) UnmatchMatch(c *gin.Context) {
 		return
 	}
 
-	result, err := s.db.Exec(`
-		UPDATE matches SET status = 'unmatched', updated_at = $1
-		WHERE id = $2 AND (job_seeker_id = $3 OR recruiter_id = $3)
-	`, time.Now(), matchID, userID)
+	// The body is optional
+	var req struct {
+		Reason string `json:"reason"`
+		Block  bool   `json:"block"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	var counterpartID uuid.UUID
+	var status models.MatchStatus
+	var jobTitle string
+	var cancelled []string
+	err = s.withTx(func(tx *sql.Tx) error {
+		cancelled = []string{}
+
+		var jobSeekerID, recruiterID uuid.UUID
+		err := tx.QueryRow(`
+			SELECT m.job_seeker_id, m.recruiter_id, m.status, j.title
+			FROM matches m
+			JOIN jobs j ON j.id = m.job_id
+			WHERE m.id = $1 AND (m.job_seeker_id = $2 OR m.recruiter_id = $2)
+			FOR UPDATE OF m
+		`, matchID, userID).Scan(&jobSeekerID, &recruiterID, &status, &jobTitle)
+		if err == sql.ErrNoRows {
+			return errMatchNotFound
+		}
+		if err != nil {
+			return err
+		}
+		counterpartID = otherParticipant(userID, jobSeekerID, recruiterID)
+
+		// Blocking still works on a match that's already over
+		if req.Block {
+			if _, err := tx.Exec(`
+				INSERT INTO user_blocks (blocker_id, blocked_id, match_id, reason)
+				VALUES ($1, $2, $3, NULLIF($4, ''))
+				ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+			`, userID, counterpartID, matchID, req.Reason); err != nil {
+				return err
+			}
+		}
+		if status == models.MatchStatusUnmatched {
+			return nil
+		}
+
+		now := time.Now()
+		if _, err := tx.Exec(`
+			UPDATE matches
+			SET status = 'unmatched', unmatched_by = $1, unmatched_at = $2, unmatch_reason = NULLIF($3, ''), updated_at = $2
+			WHERE id = $4
+		`, userID, now, req.Reason, matchID); err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(`
+			UPDATE interviews SET status = 'cancelled', updated_at = $1
+			WHERE match_id = $2 AND status = 'scheduled' AND scheduled_at > $1
+			RETURNING id
+		`, now, matchID)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			cancelled = append(cancelled, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(cancelled) > 0 {
+			if _, err := tx.Exec(`UPDATE matches SET interview_status = 'cancelled' WHERE id = $1`, matchID); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`
+			UPDATE offers SET status = 'rescinded', updated_at = $1
+			WHERE match_id = $2 AND status IN ('pending', 'countered')
+		`, now, matchID)
+		return err
+	})
+	if err == errMatchNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmatch"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
-		return
+	// A pending match was never shown to the other side. The reason and
+	// any block stay private.
+	if status == models.MatchStatusMatched {
+		s.hub.SendToUser(counterpartID, map[string]interface{}{
+			"type": "unmatched",
+			"payload": map[string]interface{}{
+				"match_id":             matchID.String(),
+				"job_title":            jobTitle,
+				"cancelled_interviews": cancelled,
+			},
+		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Unmatched successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Unmatched successfully",
+		"cancelled_interviews": cancelled,
+		"blocked":              req.Block,
+	})
+}
+
+// notBlockedSQL is a condition that neither user has blocked the other
+func notBlockedSQL(user, other string) string {
+	return fmt.Sprintf(`NOT EXISTS (
+		SELECT 1 FROM user_blocks b
+		WHERE (b.blocker_id = %[1]s AND b.blocked_id = %[2]s) OR (b.blocker_id = %[2]s AND b.blocked_id = %[1]s)
+	)`, user, other)
+}
+
+// respondInvalidTransition explains a refused status change with the
+// statuses the match can move to instead
+func respondInvalidTransition(c *gin.Context, from, to models.ApplicationStatus) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   fmt.Sprintf("Can't move an application from %s to %s", from, to),
+		"current": from,
+		"allowed": from.NextStatuses(),
+	})
 }
 
 func getStatusMessage(status models.ApplicationStatus) string {
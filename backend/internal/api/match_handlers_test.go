@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestRecruiterStatusError(t *testing.T) {
+	tests := []struct {
+		status  models.ApplicationStatus
+		allowed bool
+	}{
+		{models.ApplicationStatusReviewing, true},
+		{models.ApplicationStatusInterview, true},
+		{models.ApplicationStatusOffered, true},
+		{models.ApplicationStatusRejected, true},
+		{models.ApplicationStatusWithdrawn, false},
+		{models.ApplicationStatusHired, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if msg := recruiterStatusError(tt.status); (msg == "") != tt.allowed {
+				t.Errorf("recruiterStatusError = %q, want allowed %v", msg, tt.allowed)
+			}
+		})
+	}
+}
+
+// TestRecruiterCantHire tries to hire an offered candidate directly and in
+// bulk. Only accepting the offer may do that.
+func TestRecruiterCantHire(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	seeker := createTestUser(t, s, "job_seeker")
+	recruiter := createTestUser(t, s, "recruiter")
+
+	var jobID, matchID uuid.UUID
+	if err := s.db.QueryRow(`
+		INSERT INTO jobs (recruiter_id, title, description, job_type, company_name)
+		VALUES ($1, 'Engineer', 'Builds things', 'full_time', 'Acme') RETURNING id
+	`, recruiter.id).Scan(&jobID); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if err := s.db.QueryRow(`
+		INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, application_status, matched_at)
+		VALUES ($1, $2, $3, 'matched', 'offered', CURRENT_TIMESTAMP) RETURNING id
+	`, jobID, seeker.id, recruiter.id).Scan(&matchID); err != nil {
+		t.Fatalf("create match: %v", err)
+	}
+
+	w := recruiter.do(s, http.MethodPut, "/api/v1/matches/"+matchID.String()+"/status",
+		map[string]interface{}{"application_status": "hired"}, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status update returned %d, want 403: %s", w.Code, w.Body.String())
+	}
+
+	w = recruiter.do(s, http.MethodPost, "/api/v1/jobs/"+jobID.String()+"/matches/bulk-status",
+		map[string]interface{}{"application_status": "hired"}, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("bulk status returned %d, want 403: %s", w.Code, w.Body.String())
+	}
+
+	var status string
+	if err := s.db.QueryRow(`SELECT application_status FROM matches WHERE id = $1`, matchID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != string(models.ApplicationStatusOffered) {
+		t.Errorf("match moved to %s, want it still offered", status)
+	}
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/blowjobs-ai/backend/internal/matching"
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// feedPoolSize caps how many unseen cards are pulled from the database and
+// scored per request. The pool is ordered by skill fit, the heaviest factor
+// of the score, so a strong fit isn't crowded out by newer cards; one past
+// the cap that only scores well on the other factors can still be missed.
+const feedPoolSize = 200
+
+// skillFitSQL is the share (0-1) of the required skills found in have,
+// counted as matching.Explain does: trimmed, case-insensitive and without
+// duplicates. No required skills is a full fit.
+func skillFitSQL(required, have string) string {
+	return `COALESCE((
+		SELECT COUNT(h.skill)::float / NULLIF(COUNT(*), 0)
+		FROM (SELECT DISTINCT lower(btrim(s)) AS skill FROM unnest(` + required + `) s WHERE btrim(s) != '') r
+		LEFT JOIN (SELECT DISTINCT lower(btrim(s)) AS skill FROM unnest(` + have + `) s) h ON h.skill = r.skill
+	), 1)`
+}
+
+// Experience band tolerances selectable with ?experience=
+var experienceTolerances = map[string]int{
+	"strict": 0,
+	"loose":  2,
+	"off":    -1,
+}
+
+// feedOptions are the query parameters shared by the job and candidate feeds
+type feedOptions struct {
+	limit               int
+	minScore            int
+	workPreference      models.WorkPreference // "" means use the default
+	salary              int                   // -1 means use the default, 0 disables the filter
+	experienceTolerance int                   // years outside the band allowed, -1 disables the filter
+}
+
+// parseFeedOptions reads the feed tuning parameters. salaryParam is
+// min_salary for job seekers and max_salary for recruiters.
+func parseFeedOptions(c *gin.Context, salaryParam string) (feedOptions, error) {
+	opts := feedOptions{
+		limit:               10,
+		salary:              -1,
+		experienceTolerance: experienceTolerances["loose"],
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return opts, errors.New("limit must be a positive integer")
+		}
+		opts.limit = limit
+	}
+	if opts.limit > 50 {
+		opts.limit = 50
+	}
+
+	if raw := c.Query("min_score"); raw != "" {
+		score, err := strconv.Atoi(raw)
+		if err != nil || score < 0 || score > 100 {
+			return opts, errors.New("min_score must be between 0 and 100")
+		}
+		opts.minScore = score
+	}
+
+	if raw := c.Query("work_preference"); raw != "" {
+		switch wp := models.WorkPreference(raw); wp {
+		case models.WorkPreferenceRemote, models.WorkPreferenceHybrid,
+			models.WorkPreferenceOnsite, models.WorkPreferenceAny:
+			opts.workPreference = wp
+		default:
+			return opts, errors.New("work_preference must be one of remote, hybrid, onsite, any")
+		}
+	}
+
+	if raw := c.Query(salaryParam); raw != "" {
+		salary, err := strconv.Atoi(raw)
+		if err != nil || salary < 0 {
+			return opts, errors.New(salaryParam + " must be a non-negative integer")
+		}
+		opts.salary = salary
+	}
+
+	if raw := c.Query("experience"); raw != "" {
+		tolerance, ok := experienceTolerances[raw]
+		if !ok {
+			return opts, errors.New("experience must be one of strict, loose, off")
+		}
+		opts.experienceTolerance = tolerance
+	}
+
+	return opts, nil
+}
+
+// loadScoringProfile fetches the parts of a job seeker profile the matching
+// engine needs
+func (s *Server) loadScoringProfile(jobSeekerID uuid.UUID) (*models.JobSeekerProfile, error) {
+	var p models.JobSeekerProfile
+	err := s.db.QueryRow(`
+		SELECT id, user_id, COALESCE(skills, ARRAY[]::text[]),
+		       COALESCE(experience_level, ''), COALESCE(years_of_experience, 0),
+		       COALESCE(preferred_locations, ARRAY[]::text[]), COALESCE(work_preference, 'any'),
+		       COALESCE(expected_salary_min, 0), COALESCE(expected_salary_max, 0),
+		       COALESCE(salary_currency, 'USD'), COALESCE(open_to_relocation, false)
+		FROM job_seeker_profiles
+		WHERE user_id = $1
+	`, jobSeekerID).Scan(
+		&p.ID, &p.UserID, pq.Array(&p.Skills), &p.ExperienceLevel, &p.YearsOfExperience,
+		pq.Array(&p.PreferredLocations), &p.WorkPreference, &p.ExpectedSalaryMin,
+		&p.ExpectedSalaryMax, &p.SalaryCurrency, &p.OpenToRelocation,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// loadScoringJobs fetches the recruiter's active jobs with the fields the
+// matching engine needs
+func (s *Server) loadScoringJobs(recruiterID uuid.UUID) ([]models.Job, error) {
+	rows, err := s.db.Query(`
+		SELECT id, recruiter_id, title, COALESCE(skills, ARRAY[]::text[]),
+		       COALESCE(experience_level, ''), COALESCE(min_years_exp, 0), COALESCE(max_years_exp, 0),
+		       COALESCE(work_preference, 'any'), COALESCE(location, ''),
+		       COALESCE(salary_min, 0), COALESCE(salary_max, 0), COALESCE(salary_currency, 'USD')
+		FROM jobs
+		WHERE recruiter_id = $1 AND status = 'active'
+	`, recruiterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		var j models.Job
+		if err := rows.Scan(
+			&j.ID, &j.RecruiterID, &j.Title, pq.Array(&j.Skills), &j.ExperienceLevel,
+			&j.MinYearsExp, &j.MaxYearsExp, &j.WorkPreference, &j.Location,
+			&j.SalaryMin, &j.SalaryMax, &j.SalaryCurrency,
+		); err != nil {
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// explainMatches fills in the score and explanation of each match in place
+func (s *Server) explainMatches(matches []models.MatchWithDetails) {
+	if len(matches) == 0 {
+		return
+	}
+
+	ids := make([]string, len(matches))
+	index := make(map[uuid.UUID]int, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID.String()
+		index[m.ID] = i
+	}
+
+	rows, err := s.db.Query(`
+		SELECT m.id, j.id, COALESCE(j.skills, ARRAY[]::text[]),
+		       COALESCE(j.min_years_exp, 0), COALESCE(j.max_years_exp, 0),
+		       COALESCE(j.work_preference, 'any'), COALESCE(j.location, ''),
+		       COALESCE(j.salary_min, 0), COALESCE(j.salary_max, 0), COALESCE(j.salary_currency, 'USD'),
+		       COALESCE(p.skills, ARRAY[]::text[]), COALESCE(p.years_of_experience, 0),
+		       COALESCE(p.preferred_locations, ARRAY[]::text[]), COALESCE(p.work_preference, 'any'),
+		       COALESCE(p.expected_salary_min, 0), COALESCE(p.expected_salary_max, 0),
+		       COALESCE(p.salary_currency, 'USD'), COALESCE(p.open_to_relocation, false)
+		FROM matches m
+		JOIN jobs j ON j.id = m.job_id
+		JOIN job_seeker_profiles p ON p.user_id = m.job_seeker_id
+		WHERE m.id = ANY($1::uuid[])
+	`, pq.Array(ids))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var matchID uuid.UUID
+		var j models.Job
+		var p models.JobSeekerProfile
+		if err := rows.Scan(
+			&matchID, &j.ID, pq.Array(&j.Skills), &j.MinYearsExp, &j.MaxYearsExp,
+			&j.WorkPreference, &j.Location, &j.SalaryMin, &j.SalaryMax, &j.SalaryCurrency,
+			pq.Array(&p.Skills), &p.YearsOfExperience, pq.Array(&p.PreferredLocations),
+			&p.WorkPreference, &p.ExpectedSalaryMin, &p.ExpectedSalaryMax,
+			&p.SalaryCurrency, &p.OpenToRelocation,
+		); err != nil {
+			continue
+		}
+
+		if i, ok := index[matchID]; ok {
+			matches[i].Explanation = matching.Explain(&p, &j)
+			matches[i].Job.MatchScore = matches[i].Explanation.Score
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	errMessageNotFound    = errors.New("message not found")
+	errNotMessageSender   = errors.New("only the sender can change a message")
+	errMessageDeleted     = errors.New("message was deleted")
+	errMessageNotText     = errors.New("only text messages can be edited")
+	errMessageUndeletable = errors.New("only text and attachment messages can be deleted")
+	errEditWindowExpired  = errors.New("edit window has passed")
+)
+
+// EditMessage replaces the content of the user's own text message within
+// the edit window. The previous content is kept in message_edits.
+func (s *Server) EditMessage(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, messageID, ok := messageParams(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var msg models.Message
+	var recipientID uuid.UUID
+	var changed bool
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		recipientID, err = lockOwnMessage(tx, userID, matchID, messageID, &msg)
+		if err != nil {
+			return err
+		}
+		if msg.Type != models.MessageTypeText {
+			return errMessageNotText
+		}
+		if time.Since(msg.CreatedAt) > s.cfg.MessageEditWindow {
+			return errEditWindowExpired
+		}
+		changed = msg.Content != req.Content
+		if !changed {
+			// Don't add a history entry
+			return nil
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO message_edits (message_id, editor_id, previous_content)
+			VALUES ($1, $2, $3)
+		`, messageID, userID, msg.Content); err != nil {
+			return err
+		}
+		return tx.QueryRow(`
+			UPDATE messages SET content = $1, edited_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING content, edited_at
+		`, req.Content, messageID).Scan(&msg.Content, &msg.EditedAt)
+	})
+	if err != nil {
+		s.respondMessageChangeError(c, err)
+		return
+	}
+
+	if changed {
+		s.hub.SendToUser(recipientID, map[string]interface{}{
+			"type": "message_updated",
+			"payload": models.WSMessageUpdated{
+				MatchID:   matchID.String(),
+				MessageID: messageID.String(),
+				Content:   msg.Content,
+				EditedAt:  *msg.EditedAt,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, msg)
+}
+
+// DeleteMessage soft-deletes the user's own text or attachment message.
+// Status, interview and system messages record what happened in the
+// pipeline and stay. A deleted message stays in the database for moderation
+// and shows as a tombstone in the conversation.
+func (s *Server) DeleteMessage(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, messageID, ok := messageParams(c)
+	if !ok {
+		return
+	}
+
+	var msg models.Message
+	var recipientID uuid.UUID
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		recipientID, err = lockOwnMessage(tx, userID, matchID, messageID, &msg)
+		if err != nil {
+			return err
+		}
+		if msg.Type != models.MessageTypeText && msg.Type != models.MessageTypeAttachment {
+			return errMessageUndeletable
+		}
+
+		if err := tx.QueryRow(`
+			UPDATE messages SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1
+			RETURNING deleted_at
+		`, messageID).Scan(&msg.DeletedAt); err != nil {
+			return err
+		}
+		// The recipient may not have read it yet
+		return refreshUnreadCounts(tx, matchID)
+	})
+	if err != nil {
+		s.respondMessageChangeError(c, err)
+		return
+	}
+
+	s.hub.SendToUser(recipientID, map[string]interface{}{
+		"type": "message_deleted",
+		"payload": models.WSMessageDeleted{
+			MatchID:   matchID.String(),
+			MessageID: messageID.String(),
+			DeletedAt: *msg.DeletedAt,
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
+}
+
+// lockOwnMessage locks the conversation and one of the user's messages in
+// it for changing, and returns the other participant
+func lockOwnMessage(tx *sql.Tx, userID, matchID, messageID uuid.UUID, msg *models.Message) (uuid.UUID, error) {
+	jobSeekerID, recruiterID, err := lockConversation(tx, userID, matchID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	err = tx.QueryRow(`
+		SELECT id, match_id, sender_id, type, content, is_read, read_at, created_at, edited_at, deleted_at
+		FROM messages WHERE id = $1 AND match_id = $2
+		FOR UPDATE
+	`, messageID, matchID).Scan(
+		&msg.ID, &msg.MatchID, &msg.SenderID, &msg.Type, &msg.Content,
+		&msg.IsRead, &msg.ReadAt, &msg.CreatedAt, &msg.EditedAt, &msg.DeletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, errMessageNotFound
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if msg.SenderID != userID {
+		return uuid.Nil, errNotMessageSender
+	}
+	if msg.DeletedAt != nil {
+		return uuid.Nil, errMessageDeleted
+	}
+	return otherParticipant(userID, jobSeekerID, recruiterID), nil
+}
+
+func (s *Server) respondMessageChangeError(c *gin.Context, err error) {
+	switch err {
+	case errMatchNotFound:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+	case errMessageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+	case errNotMessageSender:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own messages"})
+	case errMessageDeleted:
+		c.JSON(http.StatusGone, gin.H{"error": "Message was deleted"})
+	case errMessageNotText:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only text messages can be edited"})
+	case errMessageUndeletable:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only text and attachment messages can be deleted"})
+	case errEditWindowExpired:
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Messages can only be edited within %s of sending", s.cfg.MessageEditWindow)})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
+	}
+}
+
+func messageParams(c *gin.Context) (matchID, messageID uuid.UUID, ok bool) {
+	matchID, err := uuid.Parse(c.Param("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return matchID, messageID, false
+	}
+	messageID, err = uuid.Parse(c.Param("message_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return matchID, messageID, false
+	}
+	return matchID, messageID, true
+}
@@ -0,0 +1,597 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/blowjobs-ai/backend/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	errOfferNotFound = errors.New("offer not found")
+	errOfferClosed   = errors.New("offer is no longer open")
+	errOfferRevised  = errors.New("offer was revised")
+	errOfferExpired  = errors.New("offer has expired")
+	errOfferCounter  = errors.New("offer was already countered")
+)
+
+const offerColumns = `
+	id, match_id, version, status, salary_amount, salary_currency, start_date,
+	COALESCE(equity, ''), bonus, COALESCE(notes, ''), expires_at, document_name, document_size,
+	counter_salary, counter_start_date, COALESCE(counter_message, ''), COALESCE(response_reason, ''),
+	responded_at, created_by, created_at, updated_at`
+
+// rowScanner is either a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// CreateOffer makes an offer on one of the recruiter's matches. If the match
+// already has an open offer, this is a new version of it and the previous
+// one is superseded.
+func (s *Server) CreateOffer(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can make offers"})
+		return
+	}
+
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req models.CreateOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	startDate, ok := parseOfferDate(req.StartDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be a date like 2025-01-31"})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	if req.Bonus != nil && *req.Bonus < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bonus can't be negative"})
+		return
+	}
+	currency, ok := parseCurrency(req.SalaryCurrency)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "salary_currency must be a three-letter ISO 4217 code like USD or EUR"})
+		return
+	}
+
+	var m *pipelineMatch
+	var offer *models.Offer
+	var notifications []notification
+	err = s.withTx(func(tx *sql.Tx) error {
+		var err error
+		notifications = nil
+		m, err = lockRecruiterMatch(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+
+		// The first offer moves the match to offered, which posts the
+		// status message
+		revision := m.Status == models.ApplicationStatusOffered
+		if !revision {
+			notifications, err = s.applyStatusChange(tx, userID, m, models.ApplicationStatusOffered, "Offer made")
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`
+			UPDATE offers SET status = 'superseded', updated_at = $1
+			WHERE match_id = $2 AND status IN ('pending', 'countered')
+		`, time.Now(), m.ID); err != nil {
+			return err
+		}
+
+		offer, err = scanOffer(tx.QueryRow(`
+			INSERT INTO offers (match_id, version, salary_amount, salary_currency, start_date, equity, bonus, notes, expires_at, created_by)
+			SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), $8, $9
+			FROM offers WHERE match_id = $1
+			RETURNING `+offerColumns,
+			m.ID, req.SalaryAmount, currency, startDate, req.Equity, req.Bonus, req.Notes, req.ExpiresAt, userID,
+		))
+		if err != nil {
+			return err
+		}
+
+		if revision {
+			if _, err := insertMessage(tx, userID, m.ID, models.MessageTypeStatus, offerMessage(offer), nil); err != nil {
+				return err
+			}
+		}
+		notifications = append(notifications, offerNotification(m.JobSeekerID, "offer", offer))
+		return nil
+	})
+	switch {
+	case err == errMatchNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	case err == errInvalidTransition:
+		respondInvalidTransition(c, m.Status, models.ApplicationStatusOffered)
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create offer"})
+		return
+	}
+
+	s.sendNotifications(notifications)
+
+	c.JSON(http.StatusCreated, offer)
+}
+
+// GetOffers lists every version of a match's offers, newest first
+func (s *Server) GetOffers(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	if _, _, err := s.chatParticipants(userID, matchID); err != nil {
+		if err == errMatchNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+offerColumns+`
+		FROM offers WHERE match_id = $1
+		ORDER BY version DESC
+	`, matchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offers"})
+		return
+	}
+	defer rows.Close()
+
+	offers := []*models.Offer{}
+	for rows.Next() {
+		offer, err := scanOffer(rows)
+		if err != nil {
+			continue
+		}
+		offers = append(offers, offer)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"offers": offers})
+}
+
+// CounterOffer lets the candidate propose other terms on the open offer.
+// The offer stops expiring until the recruiter answers with a new version.
+func (s *Server) CounterOffer(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "job_seeker" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only job seekers can counter an offer"})
+		return
+	}
+
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var req models.CounterOfferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.SalaryAmount == nil && req.StartDate == "" && strings.TrimSpace(req.Message) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Propose a salary_amount, a start_date or a message"})
+		return
+	}
+	if req.SalaryAmount != nil && *req.SalaryAmount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "salary_amount must be positive"})
+		return
+	}
+	startDate, ok := parseOfferDate(req.StartDate)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be a date like 2025-01-31"})
+		return
+	}
+
+	var offer *models.Offer
+	var notifications []notification
+	err = s.withTx(func(tx *sql.Tx) error {
+		m, err := lockJobSeekerMatch(tx, userID, matchID)
+		if err != nil {
+			return err
+		}
+		offer, err = lockCurrentOffer(tx, m.ID)
+		if err != nil {
+			return err
+		}
+		if offer == nil || m.Status != models.ApplicationStatusOffered {
+			return errNoOpenOffer
+		}
+		if offer.Status == models.OfferStatusCountered {
+			return errOfferCounter
+		}
+		if offer.ExpiresAt != nil && offer.ExpiresAt.Before(time.Now()) {
+			return errOfferExpired
+		}
+
+		offer, err = scanOffer(tx.QueryRow(`
+			UPDATE offers SET status = 'countered', counter_salary = $1, counter_start_date = $2,
+			       counter_message = NULLIF($3, ''), responded_at = $4, updated_at = $4
+			WHERE id = $5
+			RETURNING `+offerColumns,
+			req.SalaryAmount, startDate, strings.TrimSpace(req.Message), time.Now(), offer.ID,
+		))
+		if err != nil {
+			return err
+		}
+
+		if _, err := insertMessage(tx, userID, m.ID, models.MessageTypeStatus, counterMessage(offer), nil); err != nil {
+			return err
+		}
+		notifications = []notification{offerNotification(m.RecruiterID, "offer_countered", offer)}
+		return nil
+	})
+	switch {
+	case err == errMatchNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	case err == errNoOpenOffer:
+		c.JSON(http.StatusConflict, gin.H{"error": "You don't have an open offer for this job"})
+		return
+	case err == errOfferCounter:
+		c.JSON(http.StatusConflict, gin.H{"error": "You already countered this offer. Wait for the recruiter to respond"})
+		return
+	case err == errOfferExpired:
+		c.JSON(http.StatusGone, gin.H{"error": "This offer has expired"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to counter offer"})
+		return
+	}
+
+	s.sendNotifications(notifications)
+
+	c.JSON(http.StatusOK, offer)
+}
+
+// UploadOfferDocument attaches the offer letter (a PDF) to an open offer,
+// replacing any previous one
+func (s *Server) UploadOfferDocument(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can attach offer letters"})
+		return
+	}
+
+	matchID, offerID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+
+	// Check access before reading the upload
+	var jobSeekerID uuid.UUID
+	err := s.db.QueryRow(`
+		SELECT m.job_seeker_id FROM offers o
+		JOIN matches m ON m.id = o.match_id
+		WHERE o.id = $1 AND o.match_id = $2 AND m.recruiter_id = $3
+	`, offerID, matchID, userID).Scan(&jobSeekerID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload offer letter"})
+		return
+	}
+
+	maxSize := int64(s.cfg.MaxAttachmentMB) << 20
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	file, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || (err == nil && file.Size > maxSize) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File too large. Maximum size is %dMB", s.cfg.MaxAttachmentMB)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(src, head)
+	head = head[:n]
+	if !sniffMatches(head, attachmentTypes[".pdf"]) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The offer letter must be a PDF"})
+		return
+	}
+
+	key := fmt.Sprintf("offers/%s/%s.pdf", matchID, uuid.New())
+	size, err := s.storage.Put(key, io.MultiReader(bytes.NewReader(head), src))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	var offer *models.Offer
+	var previousKey sql.NullString
+	err = s.withTx(func(tx *sql.Tx) error {
+		var status models.OfferStatus
+		err := tx.QueryRow(`
+			SELECT status, document_key FROM offers WHERE id = $1 FOR UPDATE
+		`, offerID).Scan(&status, &previousKey)
+		if err == sql.ErrNoRows {
+			return errOfferNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status != models.OfferStatusPending && status != models.OfferStatusCountered {
+			return errOfferClosed
+		}
+
+		offer, err = scanOffer(tx.QueryRow(`
+			UPDATE offers SET document_key = $1, document_name = $2, document_size = $3, updated_at = $4
+			WHERE id = $5
+			RETURNING `+offerColumns,
+			key, offerDocumentName(file.Filename), size, time.Now(), offerID,
+		))
+		return err
+	})
+	if err != nil {
+		if delErr := s.storage.Delete(key); delErr != nil {
+			log.Printf("Failed to remove orphaned offer letter %s: %v", key, delErr)
+		}
+		switch err {
+		case errOfferNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Offer not found"})
+		case errOfferClosed:
+			c.JSON(http.StatusConflict, gin.H{"error": "This offer is no longer open. Make a new offer instead"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload offer letter"})
+		}
+		return
+	}
+
+	if previousKey.Valid && previousKey.String != "" {
+		if err := s.storage.Delete(previousKey.String); err != nil && err != storage.ErrNotFound {
+			log.Printf("Failed to remove replaced offer letter %s: %v", previousKey.String, err)
+		}
+	}
+
+	s.sendNotifications([]notification{offerNotification(jobSeekerID, "offer", offer)})
+
+	c.JSON(http.StatusOK, offer)
+}
+
+// DownloadOfferDocument streams an offer letter to either participant
+func (s *Server) DownloadOfferDocument(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, offerID, ok := offerParams(c)
+	if !ok {
+		return
+	}
+
+	var fileName, key sql.NullString
+	var size sql.NullInt64
+	err := s.db.QueryRow(`
+		SELECT o.document_name, o.document_key, o.document_size
+		FROM offers o
+		JOIN matches m ON m.id = o.match_id
+		WHERE o.id = $1 AND o.match_id = $2 AND (m.job_seeker_id = $3 OR m.recruiter_id = $3)
+	`, offerID, matchID, userID).Scan(&fileName, &key, &size)
+	if err == sql.ErrNoRows || (err == nil && !key.Valid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer letter not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offer letter"})
+		return
+	}
+
+	file, err := s.storage.Open(key.String)
+	if err == storage.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Offer letter not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch offer letter"})
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, size.Int64, "application/pdf", file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": fileName.String}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// lockCurrentOffer locks the match's open offer, or returns nil if it has
+// none. Lock the match first.
+func lockCurrentOffer(tx *sql.Tx, matchID uuid.UUID) (*models.Offer, error) {
+	offer, err := scanOffer(tx.QueryRow(`
+		SELECT `+offerColumns+`
+		FROM offers WHERE match_id = $1 AND status IN ('pending', 'countered')
+		ORDER BY version DESC LIMIT 1
+		FOR UPDATE
+	`, matchID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return offer, err
+}
+
+// settleOffer records the candidate's answer on the match's open offer.
+// offerID, if given, must still be the current version. Matches moved to
+// offered without an offer (by an interview result) have nothing to settle.
+func settleOffer(tx *sql.Tx, matchID uuid.UUID, offerID *uuid.UUID, status models.OfferStatus, reason string) error {
+	offer, err := lockCurrentOffer(tx, matchID)
+	if err != nil {
+		return err
+	}
+	if offer == nil {
+		if offerID != nil {
+			return errOfferRevised
+		}
+		return nil
+	}
+	if offerID != nil && *offerID != offer.ID {
+		return errOfferRevised
+	}
+	if status == models.OfferStatusAccepted && offer.ExpiresAt != nil && offer.ExpiresAt.Before(time.Now()) {
+		return errOfferExpired
+	}
+
+	_, err = tx.Exec(`
+		UPDATE offers SET status = $1, response_reason = NULLIF($2, ''), responded_at = $3, updated_at = $3
+		WHERE id = $4
+	`, status, reason, time.Now(), offer.ID)
+	return err
+}
+
+func scanOffer(row rowScanner) (*models.Offer, error) {
+	var o models.Offer
+	var documentName sql.NullString
+	var documentSize sql.NullInt64
+	err := row.Scan(
+		&o.ID, &o.MatchID, &o.Version, &o.Status, &o.SalaryAmount, &o.SalaryCurrency, &o.StartDate,
+		&o.Equity, &o.Bonus, &o.Notes, &o.ExpiresAt, &documentName, &documentSize,
+		&o.CounterSalary, &o.CounterStartDate, &o.CounterMessage, &o.ResponseReason,
+		&o.RespondedAt, &o.CreatedBy, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if documentName.Valid {
+		o.Document = &models.OfferDocument{
+			FileName: documentName.String,
+			Size:     documentSize.Int64,
+			URL:      fmt.Sprintf("/api/v1/matches/%s/offers/%s/document", o.MatchID, o.ID),
+		}
+	}
+	return &o, nil
+}
+
+func offerNotification(userID uuid.UUID, eventType string, offer *models.Offer) notification {
+	return notification{
+		userID: userID,
+		message: map[string]interface{}{
+			"type":    eventType,
+			"payload": offer,
+		},
+	}
+}
+
+// offerMessage announces a new version of an offer in the conversation
+func offerMessage(offer *models.Offer) string {
+	if offer.Version == 1 {
+		return fmt.Sprintf("📄 You've received a job offer: %d %s", offer.SalaryAmount, offer.SalaryCurrency)
+	}
+	return fmt.Sprintf("📝 The offer was updated (version %d): %d %s", offer.Version, offer.SalaryAmount, offer.SalaryCurrency)
+}
+
+func counterMessage(offer *models.Offer) string {
+	lines := []string{"💬 The candidate proposed other terms"}
+	if offer.CounterSalary != nil {
+		lines = append(lines, fmt.Sprintf("Salary: %d %s", *offer.CounterSalary, offer.SalaryCurrency))
+	}
+	if offer.CounterStartDate != nil {
+		lines = append(lines, "Start date: "+offer.CounterStartDate.Format("January 2, 2006"))
+	}
+	if offer.CounterMessage != "" {
+		lines = append(lines, offer.CounterMessage)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseOfferDate parses an optional YYYY-MM-DD date
+func parseOfferDate(value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, false
+	}
+	return &date, true
+}
+
+// parseCurrency upper-cases an optional ISO 4217 currency code, defaulting
+// to USD
+func parseCurrency(value string) (string, bool) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if code == "" {
+		return "USD", true
+	}
+	if len(code) != 3 {
+		return "", false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", false
+		}
+	}
+	return code, true
+}
+
+func offerDocumentName(fileName string) string {
+	name := filepath.Base(strings.TrimSpace(fileName))
+	if name == "." || name == string(filepath.Separator) {
+		return "offer.pdf"
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".pdf") {
+		name += ".pdf"
+	}
+	return name
+}
+
+func offerParams(c *gin.Context) (matchID, offerID uuid.UUID, ok bool) {
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return matchID, offerID, false
+	}
+	offerID, err = uuid.Parse(c.Param("offer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offer ID"})
+		return matchID, offerID, false
+	}
+	return matchID, offerID, true
+}
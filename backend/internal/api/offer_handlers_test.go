@@ -0,0 +1,26 @@
+package api
+
+import "testing"
+
+func TestParseCurrency(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"", "USD", true},
+		{"eur", "EUR", true},
+		{" GBP ", "GBP", true},
+		{"EURO", "", false},
+		{"US", "", false},
+		{"U$D", "", false},
+		{"12A", "", false},
+		{"ÉUR", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCurrency(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseCurrency(%q) = %q, %v; want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
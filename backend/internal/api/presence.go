@@ -0,0 +1,175 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/blowjobs-ai/backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+// Typing indicators are relayed at most once per typingMinInterval for a
+// user in a match. A started indicator is followed by a stopped one after
+// typingTimeout unless the client refreshes it, so a client that goes away
+// mid-sentence doesn't leave the other side watching dots forever.
+const (
+	typingMinInterval = 2 * time.Second
+	typingTimeout     = 6 * time.Second
+)
+
+// presenceWindowSQL is how long a user with open connections counts as
+// online without a heartbeat. Connected clients refresh last_seen_at every
+// heartbeat, well inside it, so it only matters for connections a crashed
+// replica never closed.
+const presenceWindowSQL = "INTERVAL '2 minutes'"
+
+// onlineSQL is whether the user in alias has a chat connection open on any
+// replica
+func onlineSQL(alias string) string {
+	return "COALESCE(" + alias + ".presence_connections > 0 AND " +
+		alias + ".last_seen_at > CURRENT_TIMESTAMP - " + presenceWindowSQL + ", false)"
+}
+
+type typingKey struct {
+	userID  uuid.UUID
+	matchID uuid.UUID
+}
+
+type typingState struct {
+	lastSent  time.Time
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// typingTracker remembers who is typing where, to throttle and expire
+// indicators
+type typingTracker struct {
+	mu     sync.Mutex
+	states map[typingKey]*typingState
+}
+
+func newTypingTracker() *typingTracker {
+	return &typingTracker{states: make(map[typingKey]*typingState)}
+}
+
+// relayTyping forwards a typing indicator from userID to recipientID
+func (s *Server) relayTyping(userID, recipientID, matchID uuid.UUID, isTyping bool) {
+	key := typingKey{userID: userID, matchID: matchID}
+	t := s.typing
+
+	t.mu.Lock()
+	state := t.states[key]
+
+	if !isTyping {
+		if state == nil {
+			// Already stopped or expired
+			t.mu.Unlock()
+			return
+		}
+		state.timer.Stop()
+		delete(t.states, key)
+		t.mu.Unlock()
+		s.sendTyping(userID, recipientID, matchID, false)
+		return
+	}
+
+	if state != nil {
+		// Still typing: push the expiry back, and only relay again once
+		// the interval has passed
+		state.expiresAt = time.Now().Add(typingTimeout)
+		state.timer.Reset(typingTimeout)
+		if time.Since(state.lastSent) < typingMinInterval {
+			t.mu.Unlock()
+			return
+		}
+		state.lastSent = time.Now()
+		t.mu.Unlock()
+		s.sendTyping(userID, recipientID, matchID, true)
+		return
+	}
+
+	state = &typingState{lastSent: time.Now(), expiresAt: time.Now().Add(typingTimeout)}
+	state.timer = time.AfterFunc(typingTimeout, func() {
+		t.mu.Lock()
+		// Stopped meanwhile, or refreshed while this was waiting for the lock
+		if t.states[key] != state || time.Now().Before(state.expiresAt) {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.states, key)
+		t.mu.Unlock()
+		s.sendTyping(userID, recipientID, matchID, false)
+	})
+	t.states[key] = state
+	t.mu.Unlock()
+
+	s.sendTyping(userID, recipientID, matchID, true)
+}
+
+func (s *Server) sendTyping(userID, recipientID, matchID uuid.UUID, isTyping bool) {
+	s.hub.SendTransient(recipientID, map[string]interface{}{
+		"type": "typing",
+		"payload": map[string]interface{}{
+			"match_id":  matchID.String(),
+			"user_id":   userID.String(),
+			"is_typing": isTyping,
+		},
+	})
+}
+
+// touchPresence records that the user is connected right now
+func (s *Server) touchPresence(userID uuid.UUID) {
+	s.db.Exec(`UPDATE users SET last_seen_at = CURRENT_TIMESTAMP WHERE id = $1`, userID)
+}
+
+// keepPresence counts a connection for the user and touches their presence
+// every heartbeat until the returned func is called, which closes the
+// connection again. Counts left past the presence window by a crashed
+// replica are dropped on the next connect.
+func (s *Server) keepPresence(userID uuid.UUID) (stop func()) {
+	s.db.Exec(`
+		UPDATE users
+		SET presence_connections = CASE
+		        WHEN last_seen_at > CURRENT_TIMESTAMP - `+presenceWindowSQL+` THEN presence_connections
+		        ELSE 0
+		    END + 1,
+		    last_seen_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, userID)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(websocket.PingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.touchPresence(userID)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		s.db.Exec(`
+			UPDATE users
+			SET presence_connections = GREATEST(presence_connections - 1, 0), last_seen_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`, userID)
+	}
+}
+
+// presenceOf builds a user's presence as seen by others, or nil if they
+// hide it. connected is onlineSQL for the user.
+func (s *Server) presenceOf(userID uuid.UUID, lastSeenAt *time.Time, connected, hidden bool) *models.Presence {
+	if hidden {
+		return nil
+	}
+	return &models.Presence{
+		Online:     connected || s.hub.IsOnline(userID),
+		LastSeenAt: lastSeenAt,
+	}
+}
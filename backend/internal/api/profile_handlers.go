@@ -4,9 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -293,17 +291,10 @@ func (s *Server) UploadCV(c *gin.Context) {
 		return
 	}
 
-	// Create uploads directory if it doesn't exist
-	uploadDir := "./uploads/cv"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload directory"})
-		return
-	}
-
 	// Generate unique filename
 	fileID := uuid.New().String()
 	filename := fmt.Sprintf("%s%s", fileID, ext)
-	filePath := filepath.Join(uploadDir, filename)
+	key := "cv/" + filename
 
 	// Save file
 	src, err := file.Open()
@@ -313,14 +304,7 @@ func (s *Server) UploadCV(c *gin.Context) {
 	}
 	defer src.Close()
 
-	dst, err := os.Create(filePath)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
-		return
-	}
-	defer dst.Close()
-
-	if _, err := io.Copy(dst, src); err != nil {
+	if _, err := s.storage.Put(key, src); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
 	}
@@ -329,7 +313,7 @@ func (s *Server) UploadCV(c *gin.Context) {
 	cvURL := fmt.Sprintf("/uploads/cv/%s", filename)
 
 	// Basic AI Analysis (placeholder - in production, use actual AI service)
-	analysis := performCVAnalysis(file.Filename, filePath)
+	analysis := performCVAnalysis(file.Filename, key)
 
 	analysisJSON, _ := json.Marshal(analysis)
 
@@ -370,7 +354,7 @@ func (s *Server) UploadCV(c *gin.Context) {
 
 // performCVAnalysis performs basic analysis on the CV
 // In production, this would use an AI service to extract skills, experience, etc.
-func performCVAnalysis(filename, filePath string) map[string]interface{} {
+func performCVAnalysis(filename, storageKey string) map[string]interface{} {
 	// Placeholder analysis - in production, integrate with AI service
 	// For now, return basic structure that can be populated by actual AI
 	
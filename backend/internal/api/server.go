@@ -7,6 +7,7 @@ import (
 
 	"github.com/blowjobs-ai/backend/internal/auth"
 	"github.com/blowjobs-ai/backend/internal/config"
+	"github.com/blowjobs-ai/backend/internal/storage"
 	"github.com/blowjobs-ai/backend/internal/websocket"
 	"github.com/gin-gonic/gin"
 )
@@ -14,17 +15,21 @@ import (
 type Server struct {
 	db         *sql.DB
 	hub        *websocket.Hub
+	storage    storage.Storage
 	cfg        *config.Config
 	jwtManager *auth.JWTManager
 	router     *gin.Engine
+	typing     *typingTracker
 }
 
-func NewServer(db *sql.DB, hub *websocket.Hub, cfg *config.Config) *Server {
+func NewServer(db *sql.DB, hub *websocket.Hub, store storage.Storage, cfg *config.Config) *Server {
 	s := &Server{
 		db:         db,
 		hub:        hub,
+		storage:    store,
 		cfg:        cfg,
 		jwtManager: auth.NewJWTManager(cfg.JWTSecret, cfg.JWTExpiration),
+		typing:     newTypingTracker(),
 	}
 
 	s.setupRouter()
@@ -42,7 +47,8 @@ func (s *Server) setupRouter() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Has-More")
 		
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -76,6 +82,9 @@ func (s *Server) setupRouter() {
 			protected.PUT("/me", s.UpdateCurrentUser)
 			protected.GET("/me/stats", s.GetUserStats)
 
+			// Real-time events missed while disconnected
+			protected.GET("/events", s.GetEvents)
+
 			// Profile routes
 			profiles := protected.Group("/profiles")
 			{
@@ -84,6 +93,8 @@ func (s *Server) setupRouter() {
 				profiles.POST("/job-seeker/cv", s.UploadCV)
 				profiles.GET("/recruiter", s.GetRecruiterProfile)
 				profiles.PUT("/recruiter", s.UpdateRecruiterProfile)
+				profiles.GET("/recruiter/match-settings", s.GetMatchSettings)
+				profiles.PUT("/recruiter/match-settings", s.UpdateMatchSettings)
 			}
 
 			// Job routes
@@ -96,6 +107,10 @@ func (s *Server) setupRouter() {
 				jobs.DELETE("/:id", s.DeleteJob)
 				jobs.GET("/feed", s.GetJobFeed)        // For job seekers
 				jobs.GET("/my-jobs", s.GetMyJobs)      // For recruiters
+
+				// Bulk changes to a job's matches (for recruiters)
+				jobs.POST("/:id/matches/bulk-status", s.BulkUpdateStatus)
+				jobs.POST("/:id/matches/bulk-message", s.BulkMessage)
 			}
 
 			// Candidate routes (for recruiters)
@@ -109,6 +124,7 @@ func (s *Server) setupRouter() {
 			swipes := protected.Group("/swipes")
 			{
 				swipes.POST("", s.RecordSwipe)
+				swipes.POST("/undo", s.UndoSwipe)
 				swipes.GET("/history", s.GetSwipeHistory)
 				swipes.DELETE("/reset", s.ResetSwipes) // Dev only
 			}
@@ -118,7 +134,17 @@ func (s *Server) setupRouter() {
 			{
 				matches.GET("", s.GetMatches)
 				matches.GET("/:id", s.GetMatch)
+				matches.GET("/:id/timeline", s.GetMatchTimeline)
 				matches.PUT("/:id/status", s.UpdateMatchStatus)
+				matches.POST("/:id/withdraw", s.WithdrawApplication)
+				matches.POST("/:id/offer/accept", s.AcceptOffer)
+				matches.POST("/:id/offer/decline", s.DeclineOffer)
+				matches.POST("/:id/offer/counter", s.CounterOffer)
+				matches.GET("/:id/offers", s.GetOffers)
+				matches.POST("/:id/offers", s.CreateOffer)
+				matches.POST("/:id/offers/:offer_id/document", s.UploadOfferDocument)
+				matches.GET("/:id/offers/:offer_id/document", s.DownloadOfferDocument)
+				matches.POST("/:id/restore", s.RestoreMatch)
 				matches.DELETE("/:id", s.UnmatchMatch)
 			}
 
@@ -126,9 +152,25 @@ func (s *Server) setupRouter() {
 			chat := protected.Group("/chat")
 			{
 				chat.GET("/conversations", s.GetConversations)
+				chat.GET("/search", s.SearchMessages)
 				chat.GET("/:match_id/messages", s.GetMessages)
 				chat.POST("/:match_id/messages", s.SendMessage)
+				chat.PUT("/:match_id/messages/:message_id", s.EditMessage)
+				chat.DELETE("/:match_id/messages/:message_id", s.DeleteMessage)
 				chat.PUT("/:match_id/read", s.MarkMessagesRead)
+				chat.POST("/:match_id/attachments", s.UploadAttachment)
+				chat.GET("/:match_id/attachments/:attachment_id", s.DownloadAttachment)
+				chat.POST("/:match_id/share-cv", s.ShareCV)
+			}
+
+			// Message template routes (for recruiters)
+			templates := protected.Group("/templates")
+			{
+				templates.GET("", s.GetTemplates)
+				templates.POST("", s.CreateTemplate)
+				templates.PUT("/:id", s.UpdateTemplate)
+				templates.DELETE("/:id", s.DeleteTemplate)
+				templates.GET("/:id/preview", s.PreviewTemplate)
 			}
 
 			// Interview routes
@@ -152,8 +194,9 @@ func (s *Server) setupRouter() {
 			}
 		}
 
-		// WebSocket route
-		v1.GET("/ws", s.authMiddleware(), s.HandleWebSocket)
+		// Real-time routes; the token may also come from the query string
+		v1.GET("/ws", s.streamAuthMiddleware(), s.HandleWebSocket)
+		v1.GET("/events/stream", s.streamAuthMiddleware(), s.StreamEvents)
 	}
 
 	s.router = r
@@ -178,16 +221,51 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := s.jwtManager.Verify(tokenString)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		s.authenticate(c, tokenString)
+	}
+}
+
+// streamAuthMiddleware is authMiddleware for long-lived connections.
+// Browsers can't set headers on a WebSocket, so the token may also come from
+// the query string (?token=) or the subprotocol list ("bearer, <token>").
+func (s *Server) streamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if tokenString == "" {
+			tokenString = c.Query("token")
+		}
+		if tokenString == "" {
+			tokenString = subprotocolToken(c.GetHeader("Sec-WebSocket-Protocol"))
+		}
+		if tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token required"})
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Set("user_type", claims.UserType)
-		c.Next()
+		s.authenticate(c, tokenString)
 	}
 }
 
+// subprotocolToken extracts the token from a "bearer, <token>" subprotocol list
+func subprotocolToken(header string) string {
+	protocols := strings.Split(header, ",")
+	for i := 0; i+1 < len(protocols); i++ {
+		if strings.TrimSpace(protocols[i]) == bearerSubprotocol {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
+}
+
+func (s *Server) authenticate(c *gin.Context, tokenString string) {
+	claims, err := s.jwtManager.Verify(tokenString)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("user_type", claims.UserType)
+	c.Next()
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxStaleDays bounds a recruiter's thresholds to a year
+const maxStaleDays = 365
+
+// lastActivitySQL is when a match last saw activity: its latest message,
+// or when it was made or restored
+const lastActivitySQL = `GREATEST(m.last_message_at, m.restored_at, COALESCE(m.matched_at, m.created_at))`
+
+// staleMatchesSQL selects and locks matches that have been silent longer
+// than the recruiter's threshold in days ($2 is the default). Matches with
+// an upcoming interview or an open offer aren't stale.
+const staleMatchesSQL = `
+	SELECT m.id, m.job_seeker_id, m.recruiter_id, j.title, ` + lastActivitySQL + `
+	FROM matches m
+	JOIN jobs j ON j.id = m.job_id
+	LEFT JOIN recruiter_profiles rp ON rp.user_id = m.recruiter_id
+	WHERE m.status = 'matched'
+	  AND %[1]s > 0
+	  AND ` + lastActivitySQL + ` < $1::timestamp - make_interval(days => %[1]s)
+	  AND NOT EXISTS (
+		SELECT 1 FROM interviews i
+		WHERE i.match_id = m.id AND i.status = 'scheduled' AND i.scheduled_at > $1
+	  )
+	  AND NOT EXISTS (
+		SELECT 1 FROM offers o WHERE o.match_id = m.id AND o.status IN ('pending', 'countered')
+	  )
+	  %[2]s
+	ORDER BY m.id
+	LIMIT $3
+	FOR UPDATE OF m SKIP LOCKED`
+
+// staleMatch is a silent match locked by the stale match worker
+type staleMatch struct {
+	id           uuid.UUID
+	jobSeekerID  uuid.UUID
+	recruiterID  uuid.UUID
+	jobTitle     string
+	lastActivity time.Time
+}
+
+// GetMatchSettings returns the recruiter's thresholds for silent matches
+func (s *Server) GetMatchSettings(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters have match settings"})
+		return
+	}
+
+	var settings models.MatchSettings
+	err := s.db.QueryRow(`
+		SELECT match_nudge_days, match_expiry_days FROM recruiter_profiles WHERE user_id = $1
+	`, userID).Scan(&settings.NudgeAfterDays, &settings.ExpireAfterDays)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch match settings"})
+		return
+	}
+
+	s.respondMatchSettings(c, settings)
+}
+
+// UpdateMatchSettings replaces the recruiter's thresholds. A null value goes
+// back to the server default.
+func (s *Server) UpdateMatchSettings(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters have match settings"})
+		return
+	}
+
+	var req models.MatchSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, days := range []*int{req.NudgeAfterDays, req.ExpireAfterDays} {
+		if days != nil && (*days < 0 || *days > maxStaleDays) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Days must be between 0 and 365"})
+			return
+		}
+	}
+	nudge := s.cfg.MatchNudgeDays
+	if req.NudgeAfterDays != nil {
+		nudge = *req.NudgeAfterDays
+	}
+	expire := s.cfg.MatchExpiryDays
+	if req.ExpireAfterDays != nil {
+		expire = *req.ExpireAfterDays
+	}
+	if nudge > 0 && expire > 0 && nudge >= expire {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The nudge must come before the match expires"})
+		return
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE recruiter_profiles SET match_nudge_days = $1, match_expiry_days = $2, updated_at = $3
+		WHERE user_id = $4
+	`, req.NudgeAfterDays, req.ExpireAfterDays, time.Now(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update match settings"})
+		return
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+
+	s.respondMatchSettings(c, req)
+}
+
+func (s *Server) respondMatchSettings(c *gin.Context, settings models.MatchSettings) {
+	c.JSON(http.StatusOK, gin.H{
+		"nudge_after_days":  settings.NudgeAfterDays,
+		"expire_after_days": settings.ExpireAfterDays,
+		"defaults": gin.H{
+			"nudge_after_days":  s.cfg.MatchNudgeDays,
+			"expire_after_days": s.cfg.MatchExpiryDays,
+		},
+	})
+}
+
+// RestoreMatch brings an expired match back, unless either side has blocked
+// the other since. It counts as activity, so the silence clock starts over.
+func (s *Server) RestoreMatch(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var jobSeekerID, recruiterID uuid.UUID
+	var jobTitle string
+	err = s.withTx(func(tx *sql.Tx) error {
+		var notBlocked bool
+		err := tx.QueryRow(`
+			SELECT m.job_seeker_id, m.recruiter_id, j.title, `+notBlockedSQL("m.job_seeker_id", "m.recruiter_id")+`
+			FROM matches m
+			JOIN jobs j ON j.id = m.job_id
+			WHERE m.id = $1 AND m.status = 'expired'
+			  AND (m.job_seeker_id = $2 OR m.recruiter_id = $2)
+			FOR UPDATE OF m
+		`, matchID, userID).Scan(&jobSeekerID, &recruiterID, &jobTitle, &notBlocked)
+		if err == sql.ErrNoRows {
+			return errMatchNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !notBlocked {
+			return errNoRematch
+		}
+
+		_, err = tx.Exec(`
+			UPDATE matches
+			SET status = 'matched', restored_at = $1, expired_at = NULL, nudged_at = NULL, updated_at = $1
+			WHERE id = $2
+		`, time.Now(), matchID)
+		return err
+	})
+	if err == errMatchNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Expired match not found"})
+		return
+	}
+	if err == errNoRematch {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This match can't be restored because one of you blocked the other"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore match"})
+		return
+	}
+
+	s.hub.SendToUser(otherParticipant(userID, jobSeekerID, recruiterID), map[string]interface{}{
+		"type": "match_restored",
+		"payload": map[string]interface{}{
+			"match_id":  matchID.String(),
+			"job_title": jobTitle,
+		},
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Match restored"})
+}
+
+// checkStaleMatches expires matches silent past the recruiter's expiry
+// threshold, then nudges both sides of those silent past the nudge
+// threshold. A match is nudged once per silence.
+func (s *Server) checkStaleMatches() error {
+	err := s.inBatches(func(tx *sql.Tx) (int, []notification, error) {
+		now := time.Now()
+		matches, err := lockStaleMatches(tx, "COALESCE(rp.match_expiry_days, $2)", "", now, s.cfg.MatchExpiryDays)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var notifications []notification
+		for _, m := range matches {
+			if _, err := tx.Exec(`
+				UPDATE matches SET status = 'expired', expired_at = $1, updated_at = $1 WHERE id = $2
+			`, now, m.id); err != nil {
+				return 0, nil, err
+			}
+			notifications = append(notifications, m.notifications("match_expired", map[string]interface{}{
+				"expired_at": now,
+			})...)
+		}
+		return len(matches), notifications, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.inBatches(func(tx *sql.Tx) (int, []notification, error) {
+		now := time.Now()
+		matches, err := lockStaleMatches(tx, "COALESCE(rp.match_nudge_days, $2)",
+			"AND (m.nudged_at IS NULL OR m.nudged_at < "+lastActivitySQL+")", now, s.cfg.MatchNudgeDays)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		var notifications []notification
+		for _, m := range matches {
+			if _, err := tx.Exec(`UPDATE matches SET nudged_at = $1 WHERE id = $2`, now, m.id); err != nil {
+				return 0, nil, err
+			}
+			notifications = append(notifications, m.notifications("match_nudge", map[string]interface{}{
+				"silent_days": int(now.Sub(m.lastActivity).Hours() / 24),
+			})...)
+		}
+		return len(matches), notifications, nil
+	})
+}
+
+func lockStaleMatches(tx *sql.Tx, thresholdSQL, extraSQL string, now time.Time, defaultDays int) ([]staleMatch, error) {
+	rows, err := tx.Query(fmt.Sprintf(staleMatchesSQL, thresholdSQL, extraSQL), now, defaultDays, workerBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []staleMatch
+	for rows.Next() {
+		var m staleMatch
+		if err := rows.Scan(&m.id, &m.jobSeekerID, &m.recruiterID, &m.jobTitle, &m.lastActivity); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// notifications is the event for both sides of the match
+func (m staleMatch) notifications(eventType string, extra map[string]interface{}) []notification {
+	payload := map[string]interface{}{
+		"match_id":         m.id.String(),
+		"job_title":        m.jobTitle,
+		"last_activity_at": m.lastActivity,
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	return []notification{
+		{userID: m.jobSeekerID, message: map[string]interface{}{"type": eventType, "payload": payload}},
+		{userID: m.recruiterID, message: map[string]interface{}{"type": eventType, "payload": payload}},
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blowjobs-ai/backend/internal/websocket"
+	"github.com/google/uuid"
+)
+
+// TestCheckStaleMatches runs the worker over a match silent past the expiry
+// threshold and one silent past the nudge threshold only
+func TestCheckStaleMatches(t *testing.T) {
+	s, hub := newTestServer(t)
+
+	seeker := createTestUser(t, s, "job_seeker")
+	recruiter := createTestUser(t, s, "recruiter")
+
+	if _, err := s.db.Exec(`
+		INSERT INTO recruiter_profiles (user_id, company_name, match_nudge_days, match_expiry_days)
+		VALUES ($1, 'Acme', 14, 30)
+	`, recruiter.id); err != nil {
+		t.Fatalf("create recruiter profile: %v", err)
+	}
+
+	newMatch := func(silentDays int) uuid.UUID {
+		t.Helper()
+		var jobID, matchID uuid.UUID
+		if err := s.db.QueryRow(`
+			INSERT INTO jobs (recruiter_id, title, description, job_type, company_name)
+			VALUES ($1, 'Engineer', 'Builds things', 'full_time', 'Acme') RETURNING id
+		`, recruiter.id).Scan(&jobID); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+		if err := s.db.QueryRow(`
+			INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, matched_at)
+			VALUES ($1, $2, $3, 'matched', CURRENT_TIMESTAMP - make_interval(days => $4))
+			RETURNING id
+		`, jobID, seeker.id, recruiter.id, silentDays).Scan(&matchID); err != nil {
+			t.Fatalf("create match: %v", err)
+		}
+		return matchID
+	}
+	expiring := newMatch(40)
+	nudged := newMatch(20)
+	fresh := newMatch(2)
+
+	seekerClient := websocket.NewClient(hub, nil, seeker.id, "job_seeker")
+	recruiterClient := websocket.NewClient(hub, nil, recruiter.id, "recruiter")
+	hub.Register(seekerClient)
+	hub.Register(recruiterClient)
+
+	if err := s.checkStaleMatches(); err != nil {
+		t.Fatalf("checkStaleMatches: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		id      uuid.UUID
+		status  string
+		nudged  bool
+		expired bool
+	}{
+		{"expiring", expiring, "expired", false, true},
+		{"nudged", nudged, "matched", true, false},
+		{"fresh", fresh, "matched", false, false},
+	} {
+		var status string
+		var nudged, expired bool
+		if err := s.db.QueryRow(`
+			SELECT status, nudged_at IS NOT NULL, expired_at IS NOT NULL FROM matches WHERE id = $1
+		`, tt.id).Scan(&status, &nudged, &expired); err != nil {
+			t.Fatal(err)
+		}
+		if status != tt.status || nudged != tt.nudged || expired != tt.expired {
+			t.Errorf("%s match: status %s, nudged %v, expired %v; want %s, %v, %v",
+				tt.name, status, nudged, expired, tt.status, tt.nudged, tt.expired)
+		}
+	}
+
+	for _, client := range []*websocket.Client{seekerClient, recruiterClient} {
+		events := drainEvents(client)
+		if events["match_expired"] != 1 || events["match_nudge"] != 1 {
+			t.Errorf("%d match_expired and %d match_nudge events, want 1 each", events["match_expired"], events["match_nudge"])
+		}
+	}
+
+	// A match is nudged once per silence
+	if err := s.checkStaleMatches(); err != nil {
+		t.Fatalf("checkStaleMatches: %v", err)
+	}
+	if n := drainEvents(seekerClient)["match_nudge"]; n != 0 {
+		t.Errorf("second pass sent %d match_nudge events, want none", n)
+	}
+}
+
+// TestRestoreBlockedMatch restores an expired match after one side blocked
+// the other, then after they unblock
+func TestRestoreBlockedMatch(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	seeker := createTestUser(t, s, "job_seeker")
+	recruiter := createTestUser(t, s, "recruiter")
+
+	var jobID, matchID uuid.UUID
+	if err := s.db.QueryRow(`
+		INSERT INTO jobs (recruiter_id, title, description, job_type, company_name)
+		VALUES ($1, 'Engineer', 'Builds things', 'full_time', 'Acme') RETURNING id
+	`, recruiter.id).Scan(&jobID); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+	if err := s.db.QueryRow(`
+		INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, matched_at, expired_at)
+		VALUES ($1, $2, $3, 'expired', CURRENT_TIMESTAMP - INTERVAL '40 days', CURRENT_TIMESTAMP)
+		RETURNING id
+	`, jobID, seeker.id, recruiter.id).Scan(&matchID); err != nil {
+		t.Fatalf("create match: %v", err)
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO user_blocks (blocker_id, blocked_id, match_id) VALUES ($1, $2, $3)
+	`, recruiter.id, seeker.id, matchID); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	restore := "/api/v1/matches/" + matchID.String() + "/restore"
+	if w := seeker.do(s, http.MethodPost, restore, nil, nil); w.Code != http.StatusForbidden {
+		t.Errorf("restore while blocked returned %d, want 403: %s", w.Code, w.Body.String())
+	}
+
+	if _, err := s.db.Exec(`DELETE FROM user_blocks WHERE blocker_id = $1`, recruiter.id); err != nil {
+		t.Fatal(err)
+	}
+	if w := seeker.do(s, http.MethodPost, restore, nil, nil); w.Code != http.StatusOK {
+		t.Errorf("restore returned %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
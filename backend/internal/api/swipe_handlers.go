@@ -2,7 +2,11 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/blowjobs-ai/backend/internal/models"
@@ -10,6 +14,16 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	errSwipeTargetNotFound    = errors.New("swipe target not found")
+	errNoRematch              = errors.New("pair unmatched or blocked each other")
+	errSuperLikeQuotaExceeded = errors.New("daily super like quota exceeded")
+	errNoSwipeToUndo          = errors.New("no swipe to undo")
+	errSwipeSuperseded        = errors.New("another swipe was recorded meanwhile")
+	errUndoWindowPassed       = errors.New("undo window has passed")
+	errSwipeMatched           = errors.New("swipe already produced a match")
+)
+
 func (s *Server) RecordSwipe(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 	userType := c.MustGet("user_type").(string)
@@ -20,116 +34,357 @@ func (s *Server) RecordSwipe(c *gin.Context) {
 		return
 	}
 
+	// Recruiters may scope the decision to one of their jobs
+	if req.JobID != nil {
+		if userType != "recruiter" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "job_id is only accepted from recruiters"})
+			return
+		}
+
+		var owned bool
+		if err := s.db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1 AND recruiter_id = $2)
+		`, *req.JobID, userID).Scan(&owned); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record swipe"})
+			return
+		}
+		if !owned {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+			return
+		}
+	}
+
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	requestJSON, _ := json.Marshal(req)
+
+	// The swipe, any match it completes, counters and badges are applied
+	// atomically; notifications go out only once they are committed
+	var outcome *swipeOutcome
+	var replay *idempotentResponse
+	err := s.withTx(func(tx *sql.Tx) error {
+		outcome, replay = nil, nil
+
+		if idempotencyKey != "" {
+			stored, err := claimIdempotencyKey(tx, userID, "swipe", idempotencyKey, hashRequest(requestJSON))
+			if err != nil {
+				return err
+			}
+			if stored != nil {
+				replay = stored
+				return nil
+			}
+		}
+
+		var err error
+		outcome, err = s.applySwipe(tx, userID, userType, req)
+		if err != nil {
+			return err
+		}
+
+		if idempotencyKey != "" {
+			body, _ := json.Marshal(outcome.response)
+			return saveIdempotentResponse(tx, userID, "swipe", idempotencyKey, http.StatusOK, body)
+		}
+		return nil
+	})
+
+	var limitErr *swipeLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		retryAfter := int(time.Until(limitErr.resetAt).Seconds()) + 1
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limitErr.limit))
+		c.Header("X-RateLimit-Remaining", "0")
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(limitErr.resetAt.Unix(), 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":    fmt.Sprintf("You've reached the limit of %d swipes per %s", limitErr.limit, limitErr.window),
+			"limit":    limitErr.limit,
+			"window":   limitErr.window,
+			"reset_at": limitErr.resetAt,
+		})
+		return
+	case errors.Is(err, errIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different swipe"})
+		return
+	case errors.Is(err, errSwipeTargetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Swipe target not found"})
+		return
+	case errors.Is(err, errSuperLikeQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":                 "You've used all your super likes for today",
+			"super_likes_remaining": 0,
+			"reset_at":              nextMidnight(time.Now()),
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record swipe"})
+		return
+	}
+
+	if replay != nil {
+		c.Data(replay.status, "application/json; charset=utf-8", replay.body)
+		return
+	}
+
+	s.sendNotifications(outcome.notifications)
+	c.JSON(http.StatusOK, outcome.response)
+}
+
+// swipeOutcome is what a swipe produced inside its transaction
+type swipeOutcome struct {
+	response      models.MatchResponse
+	notifications []notification
+}
+
+// applySwipe records a swipe and everything it causes as part of tx
+func (s *Server) applySwipe(tx *sql.Tx, userID uuid.UUID, userType string, req models.SwipeRequest) (*swipeOutcome, error) {
 	swipeType := "job"
 	if userType == "recruiter" {
 		swipeType = "profile"
 	}
 
-	// Record the swipe
-	_, err := s.db.Exec(`
-		INSERT INTO swipes (swiper_id, swiped_id, swipe_type, direction)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (swiper_id, swiped_id, swipe_type) DO UPDATE SET direction = $4
-	`, userID, req.TargetID, swipeType, req.Direction)
+	// Lock the job seeker involved before anything else, so a job seeker
+	// and a recruiter swiping on each other at the same time are serialized
+	var jobSeekerID, profileID uuid.UUID
+	var err error
+	if userType == "recruiter" {
+		profileID = req.TargetID
+		jobSeekerID, err = lockCandidate(tx, profileID)
+		if err == sql.ErrNoRows {
+			return nil, errSwipeTargetNotFound
+		}
+	} else {
+		jobSeekerID = userID
+		profileID, err = lockCandidateByUser(tx, userID)
+		if err == sql.ErrNoRows {
+			// Without a profile no recruiter can have swiped on them
+			err = nil
+		}
+		if err == nil {
+			var exists bool
+			err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM jobs WHERE id = $1)`, req.TargetID).Scan(&exists)
+			if err == nil && !exists {
+				return nil, errSwipeTargetNotFound
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Everything this swipe changes is recorded so it can be undone
+	var effects swipeEffects
+
+	// Remember an earlier decision on the same target so undo can restore it
+	var previousDirection models.SwipeDirection
+	var previousSwipedAt time.Time
+	var previousEffects []byte
+	err = tx.QueryRow(`
+		SELECT direction, created_at, COALESCE(effects, '{}') FROM swipes
+		WHERE swiper_id = $1 AND swiped_id = $2 AND swipe_type = $3
+		AND job_id IS NOT DISTINCT FROM $4::uuid
+	`, userID, req.TargetID, swipeType, req.JobID).Scan(&previousDirection, &previousSwipedAt, &previousEffects)
+	if err == nil {
+		effects.PreviousDirection = previousDirection
+		effects.PreviousSwipedAt = &previousSwipedAt
+		effects.PreviousEffects = previousEffects
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// Lock the swiper so parallel swipes can't overrun their limits
+	var locked uuid.UUID
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if err := s.checkSwipeLimits(tx, userID, userType); err != nil {
+		return nil, err
+	}
 
+	// Super likes are limited per day; repeating one doesn't use another
+	newSuperLike := req.Direction == models.SwipeUp && previousDirection != models.SwipeUp
+	if newSuperLike {
+		used, err := superLikesUsedToday(tx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= s.cfg.SuperLikesPerDay {
+			return nil, errSuperLikeQuotaExceeded
+		}
+	}
+
+	// Record the swipe
+	var swipeID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO swipes (swiper_id, swiped_id, swipe_type, direction, job_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT `+swipeConflictTarget+` DO UPDATE SET direction = $4, created_at = CURRENT_TIMESTAMP
+		RETURNING id
+	`, userID, req.TargetID, swipeType, req.Direction, req.JobID).Scan(&swipeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record swipe"})
-		return
+		return nil, err
 	}
 
 	// Update user's swipe stats
-	s.updateSwipeStats(userID)
+	if effects.Stats, err = updateSwipeStats(tx, userID); err != nil {
+		return nil, err
+	}
+
+	// Count it towards the rate limits and the super like quota, and look
+	// out for scripted swiping
+	eventID, err := logSwipeEvent(tx, userID, req.Direction, newSuperLike)
+	if err != nil {
+		return nil, err
+	}
+	if newSuperLike {
+		effects.SuperLikeEventID = &eventID
+	}
+	if err := flagIfBotLike(tx, userID); err != nil {
+		return nil, err
+	}
 
 	// Check for match only if swiped right
-	if req.Direction != models.SwipeRight && req.Direction != models.SwipeUp {
-		c.JSON(http.StatusOK, models.MatchResponse{IsMatch: false})
-		return
+	out := &swipeOutcome{response: models.MatchResponse{IsMatch: false}}
+	if req.Direction == models.SwipeRight || req.Direction == models.SwipeUp {
+		if userType == "job_seeker" {
+			// Job seeker swiped right on a job
+			err = s.handleJobSeekerSwipe(tx, jobSeekerID, profileID, req.TargetID, req.Direction, &effects, out)
+		} else {
+			// Recruiter swiped right on a profile
+			err = s.handleRecruiterSwipe(tx, userID, jobSeekerID, profileID, req.JobID, req.Direction, &effects, out)
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Handle matching logic
-	var matchResponse models.MatchResponse
+	// Let the other side know they were super-liked
+	if newSuperLike {
+		n, err := superLikeNotification(tx, userID, userType, req, jobSeekerID, profileID)
+		if err != nil {
+			return nil, err
+		}
+		out.notifications = append(out.notifications, n)
+	}
 
-	if userType == "job_seeker" {
-		// Job seeker swiped right on a job
-		matchResponse = s.handleJobSeekerSwipe(userID, req.TargetID)
-	} else {
-		// Recruiter swiped right on a profile
-		matchResponse = s.handleRecruiterSwipe(userID, req.TargetID)
+	effectsJSON, _ := json.Marshal(effects)
+	if _, err := tx.Exec(`UPDATE swipes SET effects = $1 WHERE id = $2`, effectsJSON, swipeID); err != nil {
+		return nil, err
 	}
 
-	c.JSON(http.StatusOK, matchResponse)
+	return out, nil
+}
+
+// swipeEffects records what a swipe changed besides the swipe row itself
+type swipeEffects struct {
+	PreviousDirection  models.SwipeDirection `json:"previous_direction,omitempty"` // Decision this swipe replaced
+	PreviousSwipedAt   *time.Time            `json:"previous_swiped_at,omitempty"`
+	PreviousEffects    json.RawMessage       `json:"previous_effects,omitempty"` // Effects of the decision this swipe replaced
+	PendingMatchID     *uuid.UUID            `json:"pending_match_id,omitempty"` // Pending match this swipe created
+	ApplicationCounted bool                  `json:"application_counted,omitempty"`
+	MatchID            *uuid.UUID            `json:"match_id,omitempty"`            // Match this swipe completed
+	SuperLikeEventID   *int64                `json:"super_like_event_id,omitempty"` // Swipe event that used a super like
+	Stats              *swipeStatsSnapshot   `json:"stats,omitempty"`
 }
 
-func (s *Server) handleJobSeekerSwipe(jobSeekerID, jobID uuid.UUID) models.MatchResponse {
+// swipeStatsSnapshot is the user's swipe stats and streak before a swipe
+type swipeStatsSnapshot struct {
+	LastSwipeDate *time.Time `json:"last_swipe_date,omitempty"`
+	SwipeStreak   int        `json:"swipe_streak"`
+	HadStreak     bool       `json:"had_streak"` // daily_streaks row existed
+	CurrentStreak int        `json:"current_streak"`
+	LongestStreak int        `json:"longest_streak"`
+	LastActiveAt  time.Time  `json:"last_active_at"`
+	StreakStarted time.Time  `json:"streak_started"`
+}
+
+// swipeConflictTarget matches idx_swipes_target. Swipes without a job share
+// one slot per target so legacy swipes keep their old uniqueness.
+const swipeConflictTarget = `(swiper_id, swiped_id, swipe_type, (COALESCE(job_id, '00000000-0000-0000-0000-000000000000'::uuid)))`
+
+func (s *Server) handleJobSeekerSwipe(tx *sql.Tx, jobSeekerID, profileID, jobID uuid.UUID, direction models.SwipeDirection, effects *swipeEffects, out *swipeOutcome) error {
 	// Get job details
 	var recruiterID uuid.UUID
 	var jobTitle, companyName string
-	err := s.db.QueryRow(`
+	err := tx.QueryRow(`
 		SELECT recruiter_id, title, company_name FROM jobs WHERE id = $1
 	`, jobID).Scan(&recruiterID, &jobTitle, &companyName)
 	if err != nil {
-		return models.MatchResponse{IsMatch: false}
+		return err
 	}
 
-	// Get job seeker's profile ID
-	var profileID uuid.UUID
-	s.db.QueryRow(`SELECT id FROM job_seeker_profiles WHERE user_id = $1`, jobSeekerID).Scan(&profileID)
-
-	// Check if recruiter already swiped right on this job seeker for this job
+	// Check if recruiter already swiped right on this job seeker for this job.
+	// A decision made for this exact job wins over an unscoped one.
 	var existingSwipe string
-	err = s.db.QueryRow(`
-		SELECT direction FROM swipes 
+	err = tx.QueryRow(`
+		SELECT direction FROM swipes
 		WHERE swiper_id = $1 AND swiped_id = $2 AND swipe_type = 'profile'
-	`, recruiterID, profileID).Scan(&existingSwipe)
+		AND (job_id = $3 OR job_id IS NULL)
+		ORDER BY job_id IS NULL
+		LIMIT 1
+	`, recruiterID, profileID, jobID).Scan(&existingSwipe)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
 
 	if err == sql.ErrNoRows || (existingSwipe != string(models.SwipeRight) && existingSwipe != string(models.SwipeUp)) {
 		// No match yet - create pending match entry
-		s.db.Exec(`
-			INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, job_seeker_swiped_at)
-			VALUES ($1, $2, $3, 'pending', $4)
+		var pendingID uuid.UUID
+		err = tx.QueryRow(`
+			INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, job_seeker_swiped_at, is_super_like)
+			VALUES ($1, $2, $3, 'pending', $4, $5)
 			ON CONFLICT (job_id, job_seeker_id) DO NOTHING
-		`, jobID, jobSeekerID, recruiterID, time.Now())
-
-		// Update job application count
-		s.db.Exec(`UPDATE jobs SET application_count = application_count + 1 WHERE id = $1`, jobID)
+			RETURNING id
+		`, jobID, jobSeekerID, recruiterID, time.Now(), direction == models.SwipeUp).Scan(&pendingID)
+		if err == sql.ErrNoRows {
+			// Already applied
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-		return models.MatchResponse{IsMatch: false}
+		// Update job application count, once per application
+		if _, err := tx.Exec(`UPDATE jobs SET application_count = application_count + 1 WHERE id = $1`, jobID); err != nil {
+			return err
+		}
+		effects.PendingMatchID = &pendingID
+		effects.ApplicationCounted = true
+		return nil
 	}
 
 	// It's a match!
 	now := time.Now()
-	var matchID uuid.UUID
-	err = s.db.QueryRow(`
-		INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, job_seeker_swiped_at, recruiter_swiped_at, matched_at)
-		VALUES ($1, $2, $3, 'matched', $4, $4, $4)
-		ON CONFLICT (job_id, job_seeker_id) 
-		DO UPDATE SET status = 'matched', matched_at = $4
-		RETURNING id
-	`, jobID, jobSeekerID, recruiterID, now).Scan(&matchID)
-
+	superLike := direction == models.SwipeUp || existingSwipe == string(models.SwipeUp)
+	matchID, created, err := completeMatch(tx, jobID, jobSeekerID, recruiterID, superLike, now)
+	if err == errNoRematch {
+		// The swipe is kept, but it isn't a match
+		return nil
+	}
 	if err != nil {
-		return models.MatchResponse{IsMatch: false}
+		return err
 	}
-
-	// Update match counts
-	s.db.Exec(`UPDATE users SET total_matches = total_matches + 1 WHERE id IN ($1, $2)`, jobSeekerID, recruiterID)
-	s.db.Exec(`UPDATE jobs SET match_count = match_count + 1 WHERE id = $1`, jobID)
-
-	// Award badges if applicable
-	s.checkAndAwardBadges(jobSeekerID)
-	s.checkAndAwardBadges(recruiterID)
+	effects.MatchID = &matchID
 
 	// Send real-time notification to recruiter
-	s.hub.SendToUser(recruiterID, map[string]interface{}{
-		"type": "match",
-		"payload": map[string]interface{}{
-			"match_id":  matchID.String(),
-			"job_title": jobTitle,
-			"message":   "You have a new match!",
-		},
-	})
+	if created {
+		out.notifications = append(out.notifications, notification{
+			userID: recruiterID,
+			message: map[string]interface{}{
+				"type": "match",
+				"payload": map[string]interface{}{
+					"match_id":  matchID.String(),
+					"job_title": jobTitle,
+					"message":   "You have a new match!",
+				},
+			},
+		})
+	}
 
-	return models.MatchResponse{
+	out.response = models.MatchResponse{
 		IsMatch: true,
 		Match: &models.MatchWithDetails{
 			Match: models.Match{
@@ -139,6 +394,7 @@ func (s *Server) handleJobSeekerSwipe(jobSeekerID, jobID uuid.UUID) models.Match
 				RecruiterID: recruiterID,
 				Status:      models.MatchStatusMatched,
 				MatchedAt:   &now,
+				IsSuperLike: superLike,
 			},
 			CompanyName: companyName,
 			Job: models.JobCard{
@@ -148,74 +404,88 @@ func (s *Server) handleJobSeekerSwipe(jobSeekerID, jobID uuid.UUID) models.Match
 			},
 		},
 	}
+	return nil
 }
 
-func (s *Server) handleRecruiterSwipe(recruiterID, profileID uuid.UUID) models.MatchResponse {
-	// Get job seeker user ID
-	var jobSeekerID uuid.UUID
+func (s *Server) handleRecruiterSwipe(tx *sql.Tx, recruiterID, jobSeekerID, profileID uuid.UUID, scopedJobID *uuid.UUID, direction models.SwipeDirection, effects *swipeEffects, out *swipeOutcome) error {
 	var firstName string
-	err := s.db.QueryRow(`
-		SELECT p.user_id, u.first_name FROM job_seeker_profiles p
-		JOIN users u ON u.id = p.user_id
-		WHERE p.id = $1
-	`, profileID).Scan(&jobSeekerID, &firstName)
-	if err != nil {
-		return models.MatchResponse{IsMatch: false}
+	if err := tx.QueryRow(`SELECT first_name FROM users WHERE id = $1`, jobSeekerID).Scan(&firstName); err != nil {
+		return err
 	}
 
-	// Check if job seeker already swiped right on any of this recruiter's jobs
 	var jobID uuid.UUID
 	var jobTitle, companyName string
-	err = s.db.QueryRow(`
-		SELECT j.id, j.title, j.company_name FROM jobs j
-		JOIN swipes s ON s.swiped_id = j.id
-		WHERE j.recruiter_id = $1 
-		AND s.swiper_id = $2 
-		AND s.swipe_type = 'job'
-		AND s.direction IN ('right', 'up')
-		LIMIT 1
-	`, recruiterID, jobSeekerID).Scan(&jobID, &jobTitle, &companyName)
+	var seekerDirection models.SwipeDirection
+	var err error
+	if scopedJobID != nil {
+		// Check if job seeker already swiped right on exactly this job
+		err = tx.QueryRow(`
+			SELECT j.id, j.title, j.company_name, s.direction FROM jobs j
+			JOIN swipes s ON s.swiped_id = j.id
+			WHERE j.id = $1
+			AND j.recruiter_id = $2
+			AND s.swiper_id = $3
+			AND s.swipe_type = 'job'
+			AND s.direction IN ('right', 'up')
+		`, *scopedJobID, recruiterID, jobSeekerID).Scan(&jobID, &jobTitle, &companyName, &seekerDirection)
+	} else {
+		// Check if job seeker already swiped right on any of this recruiter's jobs,
+		// skipping jobs the recruiter explicitly passed on for this candidate
+		err = tx.QueryRow(`
+			SELECT j.id, j.title, j.company_name, s.direction FROM jobs j
+			JOIN swipes s ON s.swiped_id = j.id
+			WHERE j.recruiter_id = $1
+			AND s.swiper_id = $2
+			AND s.swipe_type = 'job'
+			AND s.direction IN ('right', 'up')
+			AND NOT EXISTS (
+				SELECT 1 FROM swipes rs
+				WHERE rs.swiper_id = $1 AND rs.swiped_id = $3
+				AND rs.swipe_type = 'profile' AND rs.job_id = j.id AND rs.direction = 'left'
+			)
+			ORDER BY s.created_at
+			LIMIT 1
+		`, recruiterID, jobSeekerID, profileID).Scan(&jobID, &jobTitle, &companyName, &seekerDirection)
+	}
 
 	if err == sql.ErrNoRows {
-		// No match yet - the job seeker hasn't swiped on any jobs from this recruiter
-		return models.MatchResponse{IsMatch: false}
+		// No match yet - the job seeker hasn't swiped on a matching job from this recruiter
+		return nil
+	}
+	if err != nil {
+		return err
 	}
 
 	// It's a match!
 	now := time.Now()
-	var matchID uuid.UUID
-	err = s.db.QueryRow(`
-		INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, recruiter_swiped_at, matched_at)
-		VALUES ($1, $2, $3, 'matched', $4, $4)
-		ON CONFLICT (job_id, job_seeker_id) 
-		DO UPDATE SET status = 'matched', recruiter_swiped_at = $4, matched_at = $4
-		RETURNING id
-	`, jobID, jobSeekerID, recruiterID, now).Scan(&matchID)
-
+	superLike := direction == models.SwipeUp || seekerDirection == models.SwipeUp
+	matchID, created, err := completeMatch(tx, jobID, jobSeekerID, recruiterID, superLike, now)
+	if err == errNoRematch {
+		// The swipe is kept, but it isn't a match
+		return nil
+	}
 	if err != nil {
-		return models.MatchResponse{IsMatch: false}
+		return err
 	}
-
-	// Update match counts
-	s.db.Exec(`UPDATE users SET total_matches = total_matches + 1 WHERE id IN ($1, $2)`, jobSeekerID, recruiterID)
-	s.db.Exec(`UPDATE jobs SET match_count = match_count + 1 WHERE id = $1`, jobID)
-
-	// Award badges if applicable
-	s.checkAndAwardBadges(jobSeekerID)
-	s.checkAndAwardBadges(recruiterID)
+	effects.MatchID = &matchID
 
 	// Send real-time notification to job seeker
-	s.hub.SendToUser(jobSeekerID, map[string]interface{}{
-		"type": "match",
-		"payload": map[string]interface{}{
-			"match_id":     matchID.String(),
-			"job_title":    jobTitle,
-			"company_name": companyName,
-			"message":      "You have a new match!",
-		},
-	})
+	if created {
+		out.notifications = append(out.notifications, notification{
+			userID: jobSeekerID,
+			message: map[string]interface{}{
+				"type": "match",
+				"payload": map[string]interface{}{
+					"match_id":     matchID.String(),
+					"job_title":    jobTitle,
+					"company_name": companyName,
+					"message":      "You have a new match!",
+				},
+			},
+		})
+	}
 
-	return models.MatchResponse{
+	out.response = models.MatchResponse{
 		IsMatch: true,
 		Match: &models.MatchWithDetails{
 			Match: models.Match{
@@ -225,6 +495,7 @@ func (s *Server) handleRecruiterSwipe(recruiterID, profileID uuid.UUID) models.M
 				RecruiterID: recruiterID,
 				Status:      models.MatchStatusMatched,
 				MatchedAt:   &now,
+				IsSuperLike: superLike,
 			},
 			JobSeekerName: firstName,
 			CompanyName:   companyName,
@@ -235,57 +506,405 @@ func (s *Server) handleRecruiterSwipe(recruiterID, profileID uuid.UUID) models.M
 			},
 		},
 	}
+	return nil
+}
+
+// completeMatch marks a job seeker and job as matched, creating the match if
+// there was no pending one. Counters and badges are only updated when the
+// match is new, so a repeated swipe doesn't count it twice. A pair that
+// unmatched or blocked each other returns errNoRematch. The caller must
+// hold the job seeker's lock.
+func completeMatch(tx *sql.Tx, jobID, jobSeekerID, recruiterID uuid.UUID, superLike bool, now time.Time) (uuid.UUID, bool, error) {
+	var allowed bool
+	if err := tx.QueryRow(`SELECT `+notBlockedSQL("$1", "$2"), jobSeekerID, recruiterID).Scan(&allowed); err != nil {
+		return uuid.Nil, false, err
+	}
+	if !allowed {
+		return uuid.Nil, false, errNoRematch
+	}
+
+	var matchID uuid.UUID
+	var status models.MatchStatus
+	err := tx.QueryRow(`
+		SELECT id, status FROM matches WHERE job_id = $1 AND job_seeker_id = $2 FOR UPDATE
+	`, jobID, jobSeekerID).Scan(&matchID, &status)
+
+	switch {
+	case err == sql.ErrNoRows:
+		err = tx.QueryRow(`
+			INSERT INTO matches (job_id, job_seeker_id, recruiter_id, status, job_seeker_swiped_at, recruiter_swiped_at, matched_at, is_super_like)
+			VALUES ($1, $2, $3, 'matched', $4, $4, $4, $5)
+			RETURNING id
+		`, jobID, jobSeekerID, recruiterID, now, superLike).Scan(&matchID)
+	case err != nil:
+		return matchID, false, err
+	case status == models.MatchStatusMatched:
+		return matchID, false, nil
+	case status == models.MatchStatusUnmatched:
+		return matchID, false, errNoRematch
+	default:
+		_, err = tx.Exec(`
+			UPDATE matches
+			SET status = 'matched', matched_at = $2,
+			    job_seeker_swiped_at = COALESCE(job_seeker_swiped_at, $2),
+			    recruiter_swiped_at = COALESCE(recruiter_swiped_at, $2),
+			    is_super_like = COALESCE(is_super_like, false) OR $3
+			WHERE id = $1
+		`, matchID, now, superLike)
+	}
+	if err != nil {
+		return matchID, false, err
+	}
+
+	// Update match counts
+	if _, err := tx.Exec(`UPDATE users SET total_matches = total_matches + 1 WHERE id IN ($1, $2)`, jobSeekerID, recruiterID); err != nil {
+		return matchID, false, err
+	}
+	if _, err := tx.Exec(`UPDATE jobs SET match_count = match_count + 1 WHERE id = $1`, jobID); err != nil {
+		return matchID, false, err
+	}
+
+	// Award badges if applicable
+	if err := checkAndAwardBadges(tx, jobSeekerID); err != nil {
+		return matchID, false, err
+	}
+	if err := checkAndAwardBadges(tx, recruiterID); err != nil {
+		return matchID, false, err
+	}
+
+	return matchID, true, nil
+}
+
+// superLikesUsedToday counts the user's super likes since midnight. Changing
+// a super like to another direction doesn't give it back; only undo does.
+func superLikesUsedToday(q querier, userID uuid.UUID) (int, error) {
+	var used int
+	err := q.QueryRow(`
+		SELECT COUNT(*) FROM swipe_events
+		WHERE user_id = $1 AND super_like AND created_at >= CURRENT_DATE
+	`, userID).Scan(&used)
+	return used, err
 }
 
-func (s *Server) updateSwipeStats(userID uuid.UUID) {
+// nextMidnight is when the daily super like quota resets
+func nextMidnight(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1)
+}
+
+// superLikeNotification builds the "X super-liked you" event for whoever
+// received a super like
+func superLikeNotification(tx *sql.Tx, userID uuid.UUID, userType string, req models.SwipeRequest, jobSeekerID, profileID uuid.UUID) (notification, error) {
+	if userType == "job_seeker" {
+		var recruiterID uuid.UUID
+		var jobTitle, firstName string
+		err := tx.QueryRow(`
+			SELECT j.recruiter_id, j.title, u.first_name
+			FROM jobs j, users u
+			WHERE j.id = $1 AND u.id = $2
+		`, req.TargetID, userID).Scan(&recruiterID, &jobTitle, &firstName)
+		if err != nil {
+			return notification{}, err
+		}
+
+		return notification{
+			userID: recruiterID,
+			message: map[string]interface{}{
+				"type": "super_like",
+				"payload": map[string]interface{}{
+					"profile_id": profileID.String(),
+					"job_id":     req.TargetID.String(),
+					"job_title":  jobTitle,
+					"message":    fmt.Sprintf("%s super-liked %s", firstName, jobTitle),
+				},
+			},
+		}, nil
+	}
+
+	var companyName string
+	err := tx.QueryRow(`
+		SELECT COALESCE((SELECT company_name FROM recruiter_profiles WHERE user_id = $1), '')
+	`, userID).Scan(&companyName)
+	if err != nil {
+		return notification{}, err
+	}
+	if companyName == "" {
+		companyName = "A recruiter"
+	}
+
+	payload := map[string]interface{}{
+		"company_name": companyName,
+		"message":      companyName + " super-liked you",
+	}
+	if req.JobID != nil {
+		var jobTitle string
+		if err := tx.QueryRow(`SELECT title FROM jobs WHERE id = $1`, *req.JobID).Scan(&jobTitle); err != nil {
+			return notification{}, err
+		}
+		payload["job_id"] = req.JobID.String()
+		payload["job_title"] = jobTitle
+		payload["message"] = fmt.Sprintf("%s super-liked you for %s", companyName, jobTitle)
+	}
+
+	return notification{
+		userID: jobSeekerID,
+		message: map[string]interface{}{
+			"type":    "super_like",
+			"payload": payload,
+		},
+	}, nil
+}
+
+// updateSwipeStats counts a swipe towards the user's totals and streak and
+// returns the values they had before, for undo
+func updateSwipeStats(q querier, userID uuid.UUID) (*swipeStatsSnapshot, error) {
 	now := time.Now()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
+	snapshot := &swipeStatsSnapshot{}
+	err := q.QueryRow(`SELECT last_swipe_date, COALESCE(swipe_streak, 0) FROM users WHERE id = $1`, userID).Scan(&snapshot.LastSwipeDate, &snapshot.SwipeStreak)
+	if err != nil {
+		return nil, err
+	}
+
 	// Update total swipes
-	s.db.Exec(`UPDATE users SET total_swipes = total_swipes + 1, last_swipe_date = $1 WHERE id = $2`, now, userID)
+	if _, err := q.Exec(`UPDATE users SET total_swipes = total_swipes + 1, last_swipe_date = $1 WHERE id = $2`, now, userID); err != nil {
+		return nil, err
+	}
 
 	// Update daily streak
-	var lastActive time.Time
-	var currentStreak int
-	err := s.db.QueryRow(`SELECT last_active_at, current_streak FROM daily_streaks WHERE user_id = $1`, userID).Scan(&lastActive, &currentStreak)
+	err = q.QueryRow(`
+		SELECT last_active_at, current_streak, longest_streak, streak_started FROM daily_streaks WHERE user_id = $1
+	`, userID).Scan(&snapshot.LastActiveAt, &snapshot.CurrentStreak, &snapshot.LongestStreak, &snapshot.StreakStarted)
+	lastActive, currentStreak := snapshot.LastActiveAt, snapshot.CurrentStreak
 
 	if err == sql.ErrNoRows {
 		// Create new streak entry
-		s.db.Exec(`INSERT INTO daily_streaks (user_id, current_streak, longest_streak, last_active_at) VALUES ($1, 1, 1, $2)`, userID, now)
-		return
+		_, err = q.Exec(`INSERT INTO daily_streaks (user_id, current_streak, longest_streak, last_active_at) VALUES ($1, 1, 1, $2)`, userID, now)
+		return snapshot, err
 	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot.HadStreak = true
 
 	lastActiveDate := time.Date(lastActive.Year(), lastActive.Month(), lastActive.Day(), 0, 0, 0, 0, lastActive.Location())
 	yesterday := today.AddDate(0, 0, -1)
 
 	if lastActiveDate.Equal(today) {
 		// Already active today, just update timestamp
-		s.db.Exec(`UPDATE daily_streaks SET last_active_at = $1 WHERE user_id = $2`, now, userID)
-	} else if lastActiveDate.Equal(yesterday) {
+		_, err = q.Exec(`UPDATE daily_streaks SET last_active_at = $1 WHERE user_id = $2`, now, userID)
+		return snapshot, err
+	}
+
+	newStreak := 1
+	if lastActiveDate.Equal(yesterday) {
 		// Continue streak
-		newStreak := currentStreak + 1
-		s.db.Exec(`
-			UPDATE daily_streaks 
-			SET current_streak = $1, longest_streak = GREATEST(longest_streak, $1), last_active_at = $2 
+		newStreak = currentStreak + 1
+		_, err = q.Exec(`
+			UPDATE daily_streaks
+			SET current_streak = $1, longest_streak = GREATEST(longest_streak, $1), last_active_at = $2
 			WHERE user_id = $3
 		`, newStreak, now, userID)
-		s.db.Exec(`UPDATE users SET swipe_streak = $1 WHERE id = $2`, newStreak, userID)
 	} else {
 		// Streak broken, reset to 1
-		s.db.Exec(`
-			UPDATE daily_streaks 
-			SET current_streak = 1, last_active_at = $1, streak_started = $1 
+		_, err = q.Exec(`
+			UPDATE daily_streaks
+			SET current_streak = 1, last_active_at = $1, streak_started = $1
 			WHERE user_id = $2
 		`, now, userID)
-		s.db.Exec(`UPDATE users SET swipe_streak = 1 WHERE id = $1`, userID)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := q.Exec(`UPDATE users SET swipe_streak = $1 WHERE id = $2`, newStreak, userID); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// UndoSwipe reverts the user's most recent swipe and everything it caused,
+// as long as it is inside the undo window and didn't produce a match
+func (s *Server) UndoSwipe(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	var swipe *models.Swipe
+	err := s.withTx(func(tx *sql.Tx) error {
+		var err error
+		swipe, err = s.undoSwipe(tx, userID, userType)
+		return err
+	})
+
+	switch {
+	case errors.Is(err, errNoSwipeToUndo):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No swipe to undo"})
+		return
+	case errors.Is(err, errSwipeSuperseded):
+		c.JSON(http.StatusConflict, gin.H{"error": "Another swipe was recorded meanwhile, try again"})
+		return
+	case errors.Is(err, errUndoWindowPassed):
+		c.JSON(http.StatusConflict, gin.H{"error": "The undo window for this swipe has passed"})
+		return
+	case errors.Is(err, errSwipeMatched):
+		c.JSON(http.StatusConflict, gin.H{"error": "This swipe already produced a match and can't be undone"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to undo swipe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Swipe undone",
+		"swipe":   swipe,
+	})
+}
+
+// undoSwipe reverts the user's latest swipe inside tx
+func (s *Server) undoSwipe(tx *sql.Tx, userID uuid.UUID, userType string) (*models.Swipe, error) {
+	// Take the same job seeker lock as RecordSwipe before touching the swipe,
+	// so undo can't interleave with the other side completing the match
+	var latestID, latestTarget uuid.UUID
+	err := tx.QueryRow(`
+		SELECT id, swiped_id FROM swipes
+		WHERE swiper_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID).Scan(&latestID, &latestTarget)
+	if err == sql.ErrNoRows {
+		return nil, errNoSwipeToUndo
+	}
+	if err == nil {
+		if userType == "recruiter" {
+			_, err = lockCandidate(tx, latestTarget)
+		} else {
+			_, err = lockCandidateByUser(tx, userID)
+		}
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var swipe models.Swipe
+	var effectsJSON []byte
+	var ageSeconds float64
+	err = tx.QueryRow(`
+		SELECT id, swiped_id, swipe_type, direction, job_id, created_at, COALESCE(effects, '{}'),
+		       EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP - created_at))
+		FROM swipes
+		WHERE swiper_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, userID).Scan(
+		&swipe.ID, &swipe.SwipedID, &swipe.SwipeType, &swipe.Direction, &swipe.JobID,
+		&swipe.CreatedAt, &effectsJSON, &ageSeconds,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errNoSwipeToUndo
+	}
+	if err != nil {
+		return nil, err
+	}
+	swipe.SwiperID = userID
+
+	if swipe.ID != latestID {
+		return nil, errSwipeSuperseded
+	}
+
+	if time.Duration(ageSeconds*float64(time.Second)) > s.cfg.SwipeUndoWindow {
+		return nil, errUndoWindowPassed
+	}
+
+	var effects swipeEffects
+	json.Unmarshal(effectsJSON, &effects)
+
+	if effects.MatchID != nil {
+		return nil, errSwipeMatched
+	}
+
+	// Remove the pending match, unless the other side has matched it since
+	if effects.PendingMatchID != nil {
+		var status models.MatchStatus
+		err := tx.QueryRow(`SELECT status FROM matches WHERE id = $1 FOR UPDATE`, *effects.PendingMatchID).Scan(&status)
+		if err == nil && status == models.MatchStatusMatched {
+			return nil, errSwipeMatched
+		}
+		if _, err := tx.Exec(`DELETE FROM matches WHERE id = $1 AND status = 'pending'`, *effects.PendingMatchID); err != nil {
+			return nil, err
+		}
+	}
+
+	if effects.ApplicationCounted {
+		if _, err := tx.Exec(`
+			UPDATE jobs SET application_count = GREATEST(application_count - 1, 0) WHERE id = $1
+		`, swipe.SwipedID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Give back the super like it used
+	if effects.SuperLikeEventID != nil {
+		if _, err := tx.Exec(`
+			UPDATE swipe_events SET super_like = false WHERE id = $1 AND user_id = $2
+		`, *effects.SuperLikeEventID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Roll back swipe totals and streak
+	if _, err := tx.Exec(`UPDATE users SET total_swipes = GREATEST(total_swipes - 1, 0) WHERE id = $1`, userID); err != nil {
+		return nil, err
+	}
+	if stats := effects.Stats; stats != nil {
+		if _, err := tx.Exec(`
+			UPDATE users SET last_swipe_date = $1, swipe_streak = $2 WHERE id = $3
+		`, stats.LastSwipeDate, stats.SwipeStreak, userID); err != nil {
+			return nil, err
+		}
+
+		if stats.HadStreak {
+			_, err = tx.Exec(`
+				UPDATE daily_streaks
+				SET current_streak = $1, longest_streak = $2, last_active_at = $3, streak_started = $4
+				WHERE user_id = $5
+			`, stats.CurrentStreak, stats.LongestStreak, stats.LastActiveAt, stats.StreakStarted, userID)
+		} else {
+			_, err = tx.Exec(`DELETE FROM daily_streaks WHERE user_id = $1`, userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Restore the decision this swipe replaced, along with what it caused so
+	// that one can still be undone, or forget the target was ever swiped so
+	// it comes back into the feed
+	if effects.PreviousDirection != "" && effects.PreviousSwipedAt != nil {
+		previousEffects := effects.PreviousEffects
+		if len(previousEffects) == 0 {
+			previousEffects = json.RawMessage(`{}`)
+		}
+		_, err = tx.Exec(`
+			UPDATE swipes SET direction = $1, created_at = $2, effects = $3 WHERE id = $4
+		`, effects.PreviousDirection, *effects.PreviousSwipedAt, []byte(previousEffects), swipe.ID)
+	} else {
+		_, err = tx.Exec(`DELETE FROM swipes WHERE id = $1`, swipe.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &swipe, nil
 }
 
 func (s *Server) GetSwipeHistory(c *gin.Context) {
 	userID := c.MustGet("user_id").(uuid.UUID)
 
 	rows, err := s.db.Query(`
-		SELECT id, swiped_id, swipe_type, direction, created_at
+		SELECT id, swiped_id, swipe_type, direction, job_id, created_at
 		FROM swipes
 		WHERE swiper_id = $1
 		ORDER BY created_at DESC
@@ -301,7 +920,7 @@ func (s *Server) GetSwipeHistory(c *gin.Context) {
 	swipes := []models.Swipe{}
 	for rows.Next() {
 		var swipe models.Swipe
-		if err := rows.Scan(&swipe.ID, &swipe.SwipedID, &swipe.SwipeType, &swipe.Direction, &swipe.CreatedAt); err != nil {
+		if err := rows.Scan(&swipe.ID, &swipe.SwipedID, &swipe.SwipeType, &swipe.Direction, &swipe.JobID, &swipe.CreatedAt); err != nil {
 			continue
 		}
 		swipe.SwiperID = userID
@@ -333,6 +952,7 @@ func (s *Server) ResetSwipes(c *gin.Context) {
 	}
 	
 	// Reset swipe stats
+	s.db.Exec(`DELETE FROM swipe_events WHERE user_id = $1`, userID)
 	s.db.Exec(`UPDATE users SET total_swipes = 0, swipe_streak = 0 WHERE id = $1`, userID)
 	s.db.Exec(`UPDATE daily_streaks SET current_streak = 0 WHERE user_id = $1`, userID)
 	
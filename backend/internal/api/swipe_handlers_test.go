@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/blowjobs-ai/backend/internal/config"
+	"github.com/blowjobs-ai/backend/internal/database"
+	"github.com/blowjobs-ai/backend/internal/storage"
+	"github.com/blowjobs-ai/backend/internal/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// newTestServer returns a server on the database in TEST_DATABASE_URL,
+// skipping the test when it isn't set. The database is migrated but not
+// emptied; tests create their own users.
+func newTestServer(t *testing.T) (*Server, *websocket.Hub) {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations := []func(*sql.DB) error{
+		database.RunMigrations,
+		database.RunMigrationsV2,
+		database.RunMigrationsV3,
+		database.RunMigrationsV4,
+		database.RunMigrationsV5,
+	}
+	for _, migrate := range migrations {
+		if err := migrate(db); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("storage: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	hub := websocket.NewHub()
+	return NewServer(db, hub, store, config.Load()), hub
+}
+
+// testUser is a user created for one test, deleted with everything it owns
+// when the test ends
+type testUser struct {
+	id    uuid.UUID
+	token string
+}
+
+func createTestUser(t *testing.T, s *Server, userType string) testUser {
+	t.Helper()
+
+	email := fmt.Sprintf("test-%s@example.com", uuid.New())
+	var u testUser
+	err := s.db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, user_type) VALUES ($1, 'x', 'Test', $2)
+		RETURNING id
+	`, email, userType).Scan(&u.id)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := s.db.Exec(`DELETE FROM users WHERE id = $1`, u.id); err != nil {
+			t.Logf("delete user: %v", err)
+		}
+	})
+
+	u.token, _, err = s.jwtManager.Generate(u.id, email, userType)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	return u
+}
+
+// do sends an authenticated JSON request through the router
+func (u testUser) do(s *Server, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+u.token)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestConcurrentSwipesMatchOnce has a job seeker and a recruiter swipe right
+// on each other at the same moment, each sending its request twice with the
+// same Idempotency-Key. Whatever the interleaving, there must be exactly one
+// match, counted once.
+func TestConcurrentSwipesMatchOnce(t *testing.T) {
+	s, hub := newTestServer(t)
+
+	for i := 0; i < 25; i++ {
+		seeker := createTestUser(t, s, "job_seeker")
+		recruiter := createTestUser(t, s, "recruiter")
+
+		var profileID, jobID uuid.UUID
+		if err := s.db.QueryRow(`
+			INSERT INTO job_seeker_profiles (user_id) VALUES ($1) RETURNING id
+		`, seeker.id).Scan(&profileID); err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+		if _, err := s.db.Exec(`
+			INSERT INTO recruiter_profiles (user_id, company_name) VALUES ($1, 'Acme')
+		`, recruiter.id); err != nil {
+			t.Fatalf("create recruiter profile: %v", err)
+		}
+		if err := s.db.QueryRow(`
+			INSERT INTO jobs (recruiter_id, title, description, job_type, company_name)
+			VALUES ($1, 'Engineer', 'Builds things', 'full_time', 'Acme') RETURNING id
+		`, recruiter.id).Scan(&jobID); err != nil {
+			t.Fatalf("create job: %v", err)
+		}
+
+		seekerClient := websocket.NewClient(hub, nil, seeker.id, "job_seeker")
+		recruiterClient := websocket.NewClient(hub, nil, recruiter.id, "recruiter")
+		hub.Register(seekerClient)
+		hub.Register(recruiterClient)
+
+		swipes := []struct {
+			user testUser
+			req  map[string]interface{}
+			key  string
+		}{
+			{seeker, map[string]interface{}{"target_id": jobID, "direction": "right"}, uuid.NewString()},
+			{recruiter, map[string]interface{}{"target_id": profileID, "direction": "right"}, uuid.NewString()},
+		}
+
+		// Both sides, twice each, released together
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		responses := make([][2]*httptest.ResponseRecorder, len(swipes))
+		for j, sw := range swipes {
+			for k := 0; k < 2; k++ {
+				wg.Add(1)
+				go func(j, k int, user testUser, req map[string]interface{}, key string) {
+					defer wg.Done()
+					<-start
+					responses[j][k] = user.do(s, http.MethodPost, "/api/v1/swipes", req, map[string]string{idempotencyKeyHeader: key})
+				}(j, k, sw.user, sw.req, sw.key)
+			}
+		}
+		close(start)
+		wg.Wait()
+
+		for j, pair := range responses {
+			for _, w := range pair {
+				if w.Code != http.StatusOK {
+					t.Fatalf("run %d: swipe %d returned %d: %s", i, j, w.Code, w.Body.String())
+				}
+			}
+			if !bytes.Equal(pair[0].Body.Bytes(), pair[1].Body.Bytes()) {
+				t.Errorf("run %d: retried swipe %d got a different response:\n%s\n%s", i, j, pair[0].Body, pair[1].Body)
+			}
+		}
+
+		var matched, matches int
+		if err := s.db.QueryRow(`
+			SELECT COUNT(*) FILTER (WHERE status = 'matched'), COUNT(*) FROM matches WHERE job_id = $1
+		`, jobID).Scan(&matched, &matches); err != nil {
+			t.Fatal(err)
+		}
+		if matched != 1 || matches != 1 {
+			t.Errorf("run %d: %d matched of %d match rows, want exactly one", i, matched, matches)
+		}
+
+		var matchCount, applicationCount int
+		if err := s.db.QueryRow(`
+			SELECT match_count, application_count FROM jobs WHERE id = $1
+		`, jobID).Scan(&matchCount, &applicationCount); err != nil {
+			t.Fatal(err)
+		}
+		if matchCount != 1 {
+			t.Errorf("run %d: job match_count = %d, want 1", i, matchCount)
+		}
+		if applicationCount > 1 {
+			t.Errorf("run %d: job application_count = %d, want at most 1", i, applicationCount)
+		}
+
+		for _, u := range []testUser{seeker, recruiter} {
+			var totalMatches, totalSwipes, swipeRows int
+			if err := s.db.QueryRow(`
+				SELECT total_matches, total_swipes, (SELECT COUNT(*) FROM swipes WHERE swiper_id = $1)
+				FROM users WHERE id = $1
+			`, u.id).Scan(&totalMatches, &totalSwipes, &swipeRows); err != nil {
+				t.Fatal(err)
+			}
+			if totalMatches != 1 || totalSwipes != 1 || swipeRows != 1 {
+				t.Errorf("run %d: user has total_matches %d, total_swipes %d, %d swipe rows; want 1 each",
+					i, totalMatches, totalSwipes, swipeRows)
+			}
+		}
+
+		// Only the swipe completing the match notifies, and only the other side
+		if events := matchEvents(seekerClient) + matchEvents(recruiterClient); events != 1 {
+			t.Errorf("run %d: %d match events sent, want 1", i, events)
+		}
+	}
+}
+
+// matchEvents drains the client's queue and counts the match events in it
+func matchEvents(client *websocket.Client) int {
+	return drainEvents(client)["match"]
+}
+
+// drainEvents empties the client's queue and counts the events in it by type
+func drainEvents(client *websocket.Client) map[string]int {
+	counts := make(map[string]int)
+	for {
+		select {
+		case data := <-client.Send:
+			var msg websocket.OutgoingMessage
+			if json.Unmarshal(data, &msg) == nil {
+				counts[msg.Type]++
+			}
+		default:
+			return counts
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Velocity check: this many swipes in a row, nearly all right swipes, made
+// faster than a person can read a card, flag the account for review
+const (
+	botSampleSize      = 30
+	botRightSwipeRatio = 0.95
+	botMaxAvgInterval  = time.Second
+)
+
+// swipeLimitError is returned when a user is over their swipe limit
+type swipeLimitError struct {
+	window  string // "minute" or "day"
+	limit   int
+	resetAt time.Time
+}
+
+func (e *swipeLimitError) Error() string {
+	return fmt.Sprintf("swipe limit of %d per %s reached", e.limit, e.window)
+}
+
+// checkSwipeLimits returns a *swipeLimitError if the user can't swipe again
+// yet. The caller must hold the user's row lock so parallel requests can't
+// both slip under the limit.
+func (s *Server) checkSwipeLimits(q querier, userID uuid.UUID, userType string) error {
+	limit := s.cfg.SwipeLimits[userType]
+
+	if limit.PerMinute > 0 {
+		var count int
+		var resetAt sql.NullTime
+		err := q.QueryRow(`
+			SELECT COUNT(*), MIN(created_at) + INTERVAL '1 minute'
+			FROM swipe_events
+			WHERE user_id = $1 AND created_at > CURRENT_TIMESTAMP - INTERVAL '1 minute'
+		`, userID).Scan(&count, &resetAt)
+		if err != nil {
+			return err
+		}
+		if count >= limit.PerMinute {
+			return &swipeLimitError{window: "minute", limit: limit.PerMinute, resetAt: resetAt.Time}
+		}
+	}
+
+	if limit.PerDay > 0 {
+		var count int
+		err := q.QueryRow(`
+			SELECT COUNT(*) FROM swipe_events WHERE user_id = $1 AND created_at >= CURRENT_DATE
+		`, userID).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count >= limit.PerDay {
+			return &swipeLimitError{window: "day", limit: limit.PerDay, resetAt: nextMidnight(time.Now())}
+		}
+	}
+
+	return nil
+}
+
+// logSwipeEvent counts a swipe towards the limits. Events outlive undo and
+// reswipes, so they measure activity rather than decisions.
+func logSwipeEvent(q querier, userID uuid.UUID, direction models.SwipeDirection, superLike bool) (int64, error) {
+	var id int64
+	err := q.QueryRow(`
+		INSERT INTO swipe_events (user_id, direction, super_like) VALUES ($1, $2, $3) RETURNING id
+	`, userID, direction, superLike).Scan(&id)
+	return id, err
+}
+
+// flagIfBotLike flags the user for review when their latest swipes look
+// scripted: almost all right swipes, each within moments of the last
+func flagIfBotLike(q querier, userID uuid.UUID) error {
+	var count, rightSwipes int
+	var spanSeconds float64
+	err := q.QueryRow(`
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE direction IN ('right', 'up')),
+		       COALESCE(EXTRACT(EPOCH FROM MAX(created_at) - MIN(created_at)), 0)
+		FROM (
+			SELECT direction, created_at FROM swipe_events
+			WHERE user_id = $1
+			ORDER BY created_at DESC
+			LIMIT $2
+		) recent
+	`, userID, botSampleSize).Scan(&count, &rightSwipes, &spanSeconds)
+	if err != nil || count < botSampleSize {
+		return err
+	}
+
+	avgInterval := time.Duration(spanSeconds / float64(count-1) * float64(time.Second))
+	if float64(rightSwipes)/float64(count) < botRightSwipeRatio || avgInterval >= botMaxAvgInterval {
+		return nil
+	}
+
+	reason := fmt.Sprintf("%d of the last %d swipes were right swipes, %s apart on average", rightSwipes, count, avgInterval.Round(time.Millisecond))
+	result, err := q.Exec(`
+		UPDATE users SET flagged_for_review = true, flag_reason = $2, flagged_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND NOT COALESCE(flagged_for_review, false)
+	`, userID, reason)
+	if err != nil {
+		return err
+	}
+	if flagged, _ := result.RowsAffected(); flagged > 0 {
+		log.Printf("Flagged user %s for review: %s", userID, reason)
+	}
+	return nil
+}
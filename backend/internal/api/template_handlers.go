@@ -0,0 +1,354 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// placeholderPattern matches {{name}}, allowing spaces inside the braces
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// templatePlaceholders are the names a template may use
+var templatePlaceholders = []string{"first_name", "job_title", "company_name", "interview_time", "recruiter_name"}
+
+// templateStatusTriggers are the statuses a recruiter moves a match to, and
+// so can have an automatic message
+var templateStatusTriggers = map[models.ApplicationStatus]bool{
+	models.ApplicationStatusReviewing: true,
+	models.ApplicationStatusInterview: true,
+	models.ApplicationStatusOffered:   true,
+	models.ApplicationStatusRejected:  true,
+	models.ApplicationStatusHired:     true,
+}
+
+var errTemplateNotFound = errors.New("template not found")
+
+type templateRequest struct {
+	Name          string                    `json:"name" binding:"required"`
+	Content       string                    `json:"content" binding:"required"`
+	StatusTrigger *models.ApplicationStatus `json:"status_trigger"`
+}
+
+func (s *Server) GetTemplates(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can use message templates"})
+		return
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, recruiter_id, name, content, status_trigger, created_at, updated_at
+		FROM message_templates WHERE recruiter_id = $1
+		ORDER BY name
+	`, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.MessageTemplate{}
+	for rows.Next() {
+		var t models.MessageTemplate
+		if err := rows.Scan(
+			&t.ID, &t.RecruiterID, &t.Name, &t.Content, &t.StatusTrigger, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			continue
+		}
+		templates = append(templates, t)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates":    templates,
+		"placeholders": templatePlaceholders,
+	})
+}
+
+func (s *Server) CreateTemplate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can use message templates"})
+		return
+	}
+
+	req, ok := bindTemplateRequest(c)
+	if !ok {
+		return
+	}
+
+	var t models.MessageTemplate
+	err := s.db.QueryRow(`
+		INSERT INTO message_templates (recruiter_id, name, content, status_trigger)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, recruiter_id, name, content, status_trigger, created_at, updated_at
+	`, userID, req.Name, req.Content, req.StatusTrigger).Scan(
+		&t.ID, &t.RecruiterID, &t.Name, &t.Content, &t.StatusTrigger, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a template for this status"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+func (s *Server) UpdateTemplate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can use message templates"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	req, ok := bindTemplateRequest(c)
+	if !ok {
+		return
+	}
+
+	var t models.MessageTemplate
+	err = s.db.QueryRow(`
+		UPDATE message_templates SET name = $1, content = $2, status_trigger = $3, updated_at = $4
+		WHERE id = $5 AND recruiter_id = $6
+		RETURNING id, recruiter_id, name, content, status_trigger, created_at, updated_at
+	`, req.Name, req.Content, req.StatusTrigger, time.Now(), templateID, userID).Scan(
+		&t.ID, &t.RecruiterID, &t.Name, &t.Content, &t.StatusTrigger, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a template for this status"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+func (s *Server) DeleteTemplate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can use message templates"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	result, err := s.db.Exec(`DELETE FROM message_templates WHERE id = $1 AND recruiter_id = $2`, templateID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+}
+
+// PreviewTemplate renders a template for one of the recruiter's matches
+// without sending it
+func (s *Server) PreviewTemplate(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	if userType != "recruiter" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only recruiters can use message templates"})
+		return
+	}
+
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	matchID, err := uuid.Parse(c.Query("match_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	content, err := s.renderTemplateForMatch(templateID, userID, matchID)
+	if err == errTemplateNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if err == errMatchNotFound {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Match not found or access denied"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"content": content})
+}
+
+// bindTemplateRequest reads and validates a template, responding with 400
+// if it is invalid
+func bindTemplateRequest(c *gin.Context) (*templateRequest, bool) {
+	var req templateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	if unknown := unknownPlaceholders(req.Content); len(unknown) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":        "Unknown placeholders: " + strings.Join(unknown, ", "),
+			"placeholders": templatePlaceholders,
+		})
+		return nil, false
+	}
+	if req.StatusTrigger != nil && !templateStatusTriggers[*req.StatusTrigger] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status trigger"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// renderTemplateForMatch fills in one of the recruiter's templates for one
+// of their matches
+func (s *Server) renderTemplateForMatch(templateID, recruiterID, matchID uuid.UUID) (string, error) {
+	var content string
+	err := s.db.QueryRow(`
+		SELECT content FROM message_templates WHERE id = $1 AND recruiter_id = $2
+	`, templateID, recruiterID).Scan(&content)
+	if err == sql.ErrNoRows {
+		return "", errTemplateNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	vars, err := templateVars(s.db, recruiterID, matchID)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate(content, vars), nil
+}
+
+// statusMessage is the automatic message for a match moving to status: the
+// recruiter's template for that status if they have one, else the default
+func statusMessage(q querier, recruiterID, matchID uuid.UUID, status models.ApplicationStatus) (string, error) {
+	var content string
+	err := q.QueryRow(`
+		SELECT content FROM message_templates WHERE recruiter_id = $1 AND status_trigger = $2
+	`, recruiterID, status).Scan(&content)
+	if err == sql.ErrNoRows {
+		return getStatusMessage(status), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	vars, err := templateVars(q, recruiterID, matchID)
+	if err != nil {
+		return "", err
+	}
+	return renderTemplate(content, vars), nil
+}
+
+// templateVars resolves the placeholders for one of the recruiter's matches
+func templateVars(q querier, recruiterID, matchID uuid.UUID) (map[string]string, error) {
+	var firstName, jobTitle, companyName, recruiterName string
+	var interviewAt *time.Time
+	err := q.QueryRow(`
+		SELECT js.first_name, j.title, j.company_name, r.first_name, next.scheduled_at
+		FROM matches m
+		JOIN jobs j ON j.id = m.job_id
+		JOIN users js ON js.id = m.job_seeker_id
+		JOIN users r ON r.id = m.recruiter_id
+		LEFT JOIN LATERAL (
+			SELECT scheduled_at FROM interviews
+			WHERE match_id = m.id AND status = 'scheduled' AND scheduled_at >= CURRENT_TIMESTAMP
+			ORDER BY scheduled_at LIMIT 1
+		) next ON true
+		WHERE m.id = $1 AND m.recruiter_id = $2
+	`, matchID, recruiterID).Scan(&firstName, &jobTitle, &companyName, &recruiterName, &interviewAt)
+	if err == sql.ErrNoRows {
+		return nil, errMatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	interviewTime := "TBD"
+	if interviewAt != nil {
+		interviewTime = interviewAt.UTC().Format("Monday, January 2, 2006 at 3:04 PM") + " UTC"
+	}
+
+	return map[string]string{
+		"first_name":     firstName,
+		"job_title":      jobTitle,
+		"company_name":   companyName,
+		"interview_time": interviewTime,
+		"recruiter_name": recruiterName,
+	}, nil
+}
+
+func renderTemplate(content string, vars map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(content, func(placeholder string) string {
+		return vars[placeholderPattern.FindStringSubmatch(placeholder)[1]]
+	})
+}
+
+func unknownPlaceholders(content string) []string {
+	var unknown []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		known := false
+		for _, name := range templatePlaceholders {
+			if match[1] == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, match[1])
+		}
+	}
+	return unknown
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetMatchTimeline shows a match's hiring pipeline, oldest first: when the
+// match was made, every application status change and its interviews
+func (s *Server) GetMatchTimeline(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	matchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid match ID"})
+		return
+	}
+
+	var matchedAt time.Time
+	var status models.ApplicationStatus
+	err = s.db.QueryRow(`
+		SELECT COALESCE(matched_at, created_at), COALESCE(application_status, 'active')
+		FROM matches
+		WHERE id = $1 AND (job_seeker_id = $2 OR recruiter_id = $2)
+	`, matchID, userID).Scan(&matchedAt, &status)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Match not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timeline"})
+		return
+	}
+
+	timeline := []models.TimelineEntry{{Type: "matched", At: matchedAt}}
+
+	history, err := statusHistory(s.db, matchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timeline"})
+		return
+	}
+	timeline = append(timeline, history...)
+
+	interviews, err := interviewTimeline(s.db, matchID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch timeline"})
+		return
+	}
+	timeline = append(timeline, interviews...)
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At.Before(timeline[j].At)
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"match_id":           matchID,
+		"application_status": status,
+		"next_statuses":      status.NextStatuses(),
+		"timeline":           timeline,
+	})
+}
+
+func statusHistory(q querier, matchID uuid.UUID) ([]models.TimelineEntry, error) {
+	rows, err := q.Query(`
+		SELECT h.from_status, h.to_status, h.changed_by, COALESCE(u.first_name, ''),
+		       COALESCE(h.reason, ''), h.created_at
+		FROM application_status_history h
+		LEFT JOIN users u ON u.id = h.changed_by
+		WHERE h.match_id = $1
+		ORDER BY h.created_at
+	`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TimelineEntry
+	for rows.Next() {
+		e := models.TimelineEntry{Type: "status_change"}
+		var to models.ApplicationStatus
+		if err := rows.Scan(&e.FromStatus, &to, &e.ActorID, &e.ActorName, &e.Reason, &e.At); err != nil {
+			return nil, err
+		}
+		e.ToStatus = &to
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// interviewTimeline has an entry for each interview being scheduled, and
+// another once it was completed or cancelled
+func interviewTimeline(q querier, matchID uuid.UUID) ([]models.TimelineEntry, error) {
+	rows, err := q.Query(`
+		SELECT id, scheduled_at, COALESCE(status, 'scheduled'), COALESCE(result, ''), created_at, updated_at
+		FROM interviews
+		WHERE match_id = $1
+	`, matchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.TimelineEntry
+	for rows.Next() {
+		var id uuid.UUID
+		var scheduledAt, createdAt, updatedAt time.Time
+		var status models.InterviewStatus
+		var result string
+		if err := rows.Scan(&id, &scheduledAt, &status, &result, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, models.TimelineEntry{
+			Type:        "interview_scheduled",
+			At:          createdAt,
+			InterviewID: &id,
+			ScheduledAt: &scheduledAt,
+		})
+		switch status {
+		case models.InterviewStatusCompleted:
+			entries = append(entries, models.TimelineEntry{
+				Type:        "interview_completed",
+				At:          updatedAt,
+				InterviewID: &id,
+				ScheduledAt: &scheduledAt,
+				Result:      result,
+			})
+		case models.InterviewStatusCancelled:
+			entries = append(entries, models.TimelineEntry{
+				Type:        "interview_cancelled",
+				At:          updatedAt,
+				InterviewID: &id,
+				ScheduledAt: &scheduledAt,
+			})
+		}
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx, so helpers can run
+// on their own or as part of a larger transaction
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// txAttempts is how many times a transaction is tried when Postgres aborts
+// it because of a deadlock or serialization failure
+const txAttempts = 3
+
+// withTx runs fn in a transaction and commits it if fn returns nil. Deadlocks
+// and serialization failures are retried, so fn must not have side effects
+// outside the transaction.
+func (s *Server) withTx(fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < txAttempts; attempt++ {
+		err = s.runTx(fn)
+		if !isRetryableTxError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (s *Server) runTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	// 40001 serialization_failure, 40P01 deadlock_detected
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// notification is a real-time message to deliver once the transaction that
+// caused it has committed
+type notification struct {
+	userID  uuid.UUID
+	message map[string]interface{}
+}
+
+func (s *Server) sendNotifications(notifications []notification) {
+	for _, n := range notifications {
+		s.hub.SendToUser(n.userID, n.message)
+	}
+}
+
+// lockCandidate locks a job seeker's profile row for the rest of the
+// transaction. Every swipe involving a job seeker takes this lock first, so
+// both sides of a match are serialized and can't miss each other.
+func lockCandidate(tx *sql.Tx, profileID uuid.UUID) (uuid.UUID, error) {
+	var jobSeekerID uuid.UUID
+	err := tx.QueryRow(`SELECT user_id FROM job_seeker_profiles WHERE id = $1 FOR UPDATE`, profileID).Scan(&jobSeekerID)
+	return jobSeekerID, err
+}
+
+// lockCandidateByUser is lockCandidate for a job seeker's user ID
+func lockCandidateByUser(tx *sql.Tx, jobSeekerID uuid.UUID) (uuid.UUID, error) {
+	var profileID uuid.UUID
+	err := tx.QueryRow(`SELECT id FROM job_seeker_profiles WHERE user_id = $1 FOR UPDATE`, jobSeekerID).Scan(&profileID)
+	return profileID, err
+}
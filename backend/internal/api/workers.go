@@ -0,0 +1,170 @@
+package api
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// workerBatchSize is how many matches a background job handles per
+// transaction
+const workerBatchSize = 50
+
+// eventPruneInterval is how often stored events past the retention window
+// are deleted, eventPruneBatchSize how many per statement
+const (
+	eventPruneInterval  = time.Hour
+	eventPruneBatchSize = 1000
+)
+
+// RunWorkers starts the background jobs. They skip rows another replica is
+// working on, so every replica can run them.
+func (s *Server) RunWorkers() {
+	go s.runEvery("offer expiry", s.cfg.OfferExpiryCheck, s.expireOffers)
+	go s.runEvery("stale matches", s.cfg.StaleMatchCheck, s.checkStaleMatches)
+	if s.cfg.EventRetention > 0 {
+		go s.runEvery("event pruning", eventPruneInterval, s.pruneEvents)
+	}
+}
+
+// runEvery runs job on a fixed interval. A zero interval disables it.
+func (s *Server) runEvery(name string, interval time.Duration, job func() error) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := job(); err != nil {
+			log.Printf("Background job %s failed: %v", name, err)
+		}
+	}
+}
+
+// inBatches runs batch in its own transaction until it handles fewer than
+// workerBatchSize rows, sending each batch's notifications once it commits
+func (s *Server) inBatches(batch func(tx *sql.Tx) (int, []notification, error)) error {
+	for {
+		var handled int
+		var notifications []notification
+		err := s.withTx(func(tx *sql.Tx) error {
+			var err error
+			handled, notifications, err = batch(tx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		s.sendNotifications(notifications)
+
+		if handled < workerBatchSize {
+			return nil
+		}
+	}
+}
+
+// pruneEvents deletes stored events older than the retention window, a batch
+// at a time so new events and replays aren't held up
+func (s *Server) pruneEvents() error {
+	before := time.Now().Add(-s.cfg.EventRetention)
+	for {
+		result, err := s.db.Exec(`
+			DELETE FROM user_events WHERE (user_id, seq) IN (
+				SELECT user_id, seq FROM user_events WHERE created_at < $1 LIMIT $2
+			)
+		`, before, eventPruneBatchSize)
+		if err != nil {
+			return err
+		}
+		if deleted, _ := result.RowsAffected(); deleted < eventPruneBatchSize {
+			return nil
+		}
+	}
+}
+
+// expireOffers closes pending offers past their expiry date. The match
+// leaves the pipeline as withdrawn, and both sides are told.
+func (s *Server) expireOffers() error {
+	return s.inBatches(func(tx *sql.Tx) (int, []notification, error) {
+		rows, err := tx.Query(`
+			SELECT m.id, m.job_seeker_id, m.recruiter_id, m.application_status, j.title
+			FROM matches m
+			JOIN jobs j ON j.id = m.job_id
+			WHERE m.status = 'matched' AND EXISTS (
+				SELECT 1 FROM offers o
+				WHERE o.match_id = m.id AND o.status = 'pending' AND o.expires_at <= $1
+			)
+			ORDER BY m.id
+			LIMIT $2
+			FOR UPDATE OF m SKIP LOCKED
+		`, time.Now(), workerBatchSize)
+		if err != nil {
+			return 0, nil, err
+		}
+		var matches []*pipelineMatch
+		for rows.Next() {
+			m := &pipelineMatch{}
+			if err := rows.Scan(&m.ID, &m.JobSeekerID, &m.RecruiterID, &m.Status, &m.JobTitle); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			matches = append(matches, m)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		var notifications []notification
+		for _, m := range matches {
+			expired, err := expireOffer(tx, m)
+			if err != nil {
+				return 0, nil, err
+			}
+			notifications = append(notifications, expired...)
+		}
+		return len(matches), notifications, nil
+	})
+}
+
+// expireOffer expires a locked match's pending offer
+func expireOffer(tx *sql.Tx, m *pipelineMatch) ([]notification, error) {
+	var offerID uuid.UUID
+	err := tx.QueryRow(`
+		UPDATE offers SET status = 'expired', updated_at = $1
+		WHERE match_id = $2 AND status = 'pending' AND expires_at <= $1
+		RETURNING id
+	`, time.Now(), m.ID).Scan(&offerID)
+	if err == sql.ErrNoRows {
+		// Answered or revised meanwhile
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Status.CanTransitionTo(models.ApplicationStatusWithdrawn) {
+		if err := changeStatus(tx, uuid.Nil, m, models.ApplicationStatusWithdrawn, "Offer expired"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := insertMessage(tx, m.RecruiterID, m.ID, models.MessageTypeSystem, "⌛ The offer expired without a response", nil); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"match_id":   m.ID.String(),
+		"offer_id":   offerID.String(),
+		"job_title":  m.JobTitle,
+		"new_status": m.Status,
+	}
+	return []notification{
+		{userID: m.JobSeekerID, message: map[string]interface{}{"type": "offer_expired", "payload": payload}},
+		{userID: m.RecruiterID, message: map[string]interface{}{"type": "offer_expired", "payload": payload}},
+	}, nil
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/blowjobs-ai/backend/internal/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	gorilla "github.com/gorilla/websocket"
+)
+
+// bearerSubprotocol is the subprotocol clients send ahead of their token
+// ("bearer, <token>"); it is echoed back to accept the connection
+const bearerSubprotocol = "bearer"
+
+// wsReplayLimit is how many missed events are replayed on connect. It stays
+// below the client's send buffer so replay can't overflow it.
+const wsReplayLimit = 200
+
+// HandleWebSocket upgrades the request and keeps the connection registered
+// with the hub until it closes
+func (s *Server) HandleWebSocket(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+	userType := c.MustGet("user_type").(string)
+
+	upgrader := gorilla.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    []string{bearerSubprotocol},
+		CheckOrigin:     s.checkOrigin,
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already responded
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	client := websocket.NewClient(s.hub, conn, userID, userType)
+	s.hub.Register(client)
+
+	go client.WritePump()
+
+	// Catch up on what the client missed while disconnected
+	if since, err := strconv.ParseInt(c.Query("since"), 10, 64); err == nil && since >= 0 {
+		events, err := s.hub.Replay(client, since, wsReplayLimit)
+		if err != nil {
+			log.Printf("Failed to replay events for %s: %v", userID, err)
+		} else if len(events) == wsReplayLimit {
+			// The rest won't fit in the send buffer; the client pages
+			// through GET /events from here
+			client.SendMessage("replay_incomplete", map[string]interface{}{
+				"since": events[len(events)-1].Seq,
+			})
+		}
+	}
+
+	stopPresence := s.keepPresence(userID)
+	defer stopPresence()
+
+	client.ReadPump(s.handleClientMessage)
+}
+
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range s.cfg.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// handleClientMessage dispatches a message sent by a connected client
+func (s *Server) handleClientMessage(client *websocket.Client, msg websocket.IncomingMessage) {
+	var payload struct {
+		MatchID  uuid.UUID `json:"match_id"`
+		Content  string    `json:"content"`
+		IsTyping *bool     `json:"is_typing"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		sendClientError(client, msg.Type, "Invalid payload")
+		return
+	}
+
+	switch msg.Type {
+	case "chat_message":
+		if payload.Content == "" {
+			sendClientError(client, msg.Type, "content is required")
+			return
+		}
+		message, err := s.sendChatMessage(client.UserID, payload.MatchID, payload.Content)
+		if err == errMatchNotFound {
+			sendClientError(client, msg.Type, "Match not found or access denied")
+			return
+		}
+		if err != nil {
+			sendClientError(client, msg.Type, "Failed to send message")
+			return
+		}
+		client.SendMessage("chat_message_sent", message)
+
+	case "typing":
+		jobSeekerID, recruiterID, err := s.chatParticipants(client.UserID, payload.MatchID)
+		if err != nil {
+			sendClientError(client, msg.Type, "Match not found or access denied")
+			return
+		}
+		isTyping := payload.IsTyping == nil || *payload.IsTyping
+		s.relayTyping(client.UserID, otherParticipant(client.UserID, jobSeekerID, recruiterID), payload.MatchID, isTyping)
+
+	case "read":
+		if _, err := s.markMessagesRead(client.UserID, payload.MatchID); err != nil {
+			sendClientError(client, msg.Type, "Failed to mark messages read")
+		}
+
+	default:
+		sendClientError(client, msg.Type, "Unknown message type")
+	}
+}
+
+func sendClientError(client *websocket.Client, msgType, message string) {
+	client.SendMessage("error", map[string]interface{}{
+		"type":  msgType,
+		"error": message,
+	})
+}
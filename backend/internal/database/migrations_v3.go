@@ -0,0 +1,65 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RunMigrationsV3 adds job-scoped swipes, swipe undo, super likes, swipe
+// limits, idempotency keys and matching improvements
+func RunMigrationsV3(db *sql.DB) error {
+	migrations := []string{
+		// Recruiters swipe on a candidate for a specific job
+		`ALTER TABLE swipes ADD COLUMN IF NOT EXISTS job_id UUID REFERENCES jobs(id) ON DELETE CASCADE`,
+
+		// A recruiter can now decide on the same profile once per job
+		`ALTER TABLE swipes DROP CONSTRAINT IF EXISTS swipes_swiper_id_swiped_id_swipe_type_key`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_swipes_target
+		 ON swipes (swiper_id, swiped_id, swipe_type, COALESCE(job_id, '00000000-0000-0000-0000-000000000000'::uuid))`,
+
+		// What each swipe changed, so it can be undone
+		`ALTER TABLE swipes ADD COLUMN IF NOT EXISTS effects JSONB DEFAULT '{}'`,
+		`CREATE INDEX IF NOT EXISTS idx_swipes_swiper_created ON swipes(swiper_id, created_at DESC)`,
+
+		// Matches that started from a super like
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS is_super_like BOOLEAN DEFAULT false`,
+
+		// Every swipe made, for rate limits and bot detection
+		`CREATE TABLE IF NOT EXISTS swipe_events (
+			id BIGSERIAL PRIMARY KEY,
+			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			direction VARCHAR(10) NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_swipe_events_user_created ON swipe_events(user_id, created_at DESC)`,
+		// Swipes that used up a super like, for the daily quota. Re-swiping
+		// a target rewrites its swipe row, so the quota can't be counted there.
+		`ALTER TABLE swipe_events ADD COLUMN IF NOT EXISTS super_like BOOLEAN`,
+		`UPDATE swipe_events SET super_like = (direction = 'up') WHERE super_like IS NULL`,
+
+		// Accounts flagged by the swipe velocity check
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS flagged_for_review BOOLEAN DEFAULT false`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS flag_reason TEXT`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS flagged_at TIMESTAMP`,
+
+		// Responses to requests sent with an Idempotency-Key, replayed on retry
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			scope VARCHAR(50) NOT NULL,
+			key VARCHAR(255) NOT NULL,
+			request_hash VARCHAR(64) NOT NULL,
+			status_code INTEGER,
+			response JSONB,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, scope, key)
+		)`,
+	}
+
+	for i, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("migration v3 %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RunMigrationsV4 adds real-time event delivery across replicas, a durable
+// per-user event log, and chat presence, read state, editing, paging,
+// search and attachments
+func RunMigrationsV4(db *sql.DB) error {
+	migrations := []string{
+		// Hub events too large for a NOTIFY payload
+		`CREATE TABLE IF NOT EXISTS hub_payloads (
+			id BIGSERIAL PRIMARY KEY,
+			payload JSONB NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_hub_payloads_created ON hub_payloads(created_at)`,
+
+		// Every event sent to a user, numbered per user for replay
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS event_seq BIGINT DEFAULT 0`,
+		`CREATE TABLE IF NOT EXISTS user_events (
+			user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			seq BIGINT NOT NULL,
+			type VARCHAR(50) NOT NULL,
+			payload JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, seq)
+		)`,
+		// For pruning events past the retention window
+		`CREATE INDEX IF NOT EXISTS idx_user_events_created ON user_events(created_at)`,
+
+		// Presence
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS hide_presence BOOLEAN DEFAULT false`,
+
+		// Edited and soft-deleted messages, with every previous version kept
+		// for moderation
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP`,
+		`ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+		`CREATE TABLE IF NOT EXISTS message_edits (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			message_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+			editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+			previous_content TEXT NOT NULL,
+			edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_edits_message ON message_edits(message_id)`,
+
+		// Unread counts per participant, replacing the shared matches.unread_count.
+		// The columns are added without a default so the matches that existed
+		// before are left NULL, recounted from messages once, and never again.
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS job_seeker_unread_count INTEGER`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS recruiter_unread_count INTEGER`,
+		`ALTER TABLE matches ALTER COLUMN job_seeker_unread_count SET DEFAULT 0`,
+		`ALTER TABLE matches ALTER COLUMN recruiter_unread_count SET DEFAULT 0`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_unread ON messages(match_id) WHERE is_read = false`,
+		`UPDATE matches m SET
+			job_seeker_unread_count = (
+				SELECT COUNT(*) FROM messages
+				WHERE match_id = m.id AND sender_id IS DISTINCT FROM m.job_seeker_id AND is_read = false AND deleted_at IS NULL
+			),
+			recruiter_unread_count = (
+				SELECT COUNT(*) FROM messages
+				WHERE match_id = m.id AND sender_id IS DISTINCT FROM m.recruiter_id AND is_read = false AND deleted_at IS NULL
+			)
+		WHERE m.job_seeker_unread_count IS NULL OR m.recruiter_unread_count IS NULL`,
+
+		// Keyset pagination and full-text search over messages
+		`CREATE INDEX IF NOT EXISTS idx_messages_match_created ON messages(match_id, created_at, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_messages_search ON messages USING GIN (to_tsvector('english', content))`,
+
+		// Files shared in chat
+		`CREATE TABLE IF NOT EXISTS message_attachments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			message_id UUID REFERENCES messages(id) ON DELETE CASCADE,
+			match_id UUID REFERENCES matches(id) ON DELETE CASCADE,
+			uploader_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			file_name TEXT NOT NULL,
+			content_type VARCHAR(100) NOT NULL,
+			size_bytes BIGINT NOT NULL,
+			storage_key TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_attachments_message ON message_attachments(message_id)`,
+	}
+
+	for i, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("migration v4 %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RunMigrationsV5 adds recruiter tooling for managing the hiring pipeline
+func RunMigrationsV5(db *sql.DB) error {
+	migrations := []string{
+		// Reusable recruiter messages. A template with a status_trigger
+		// replaces the default message sent when a match moves to that status.
+		`CREATE TABLE IF NOT EXISTS message_templates (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			recruiter_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(100) NOT NULL,
+			content TEXT NOT NULL,
+			status_trigger VARCHAR(20),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_message_templates_recruiter ON message_templates(recruiter_id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_message_templates_trigger
+			ON message_templates(recruiter_id, status_trigger) WHERE status_trigger IS NOT NULL`,
+
+		// Every application status change, for the match timeline.
+		// from_status is NULL for the initial status.
+		`CREATE TABLE IF NOT EXISTS application_status_history (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			match_id UUID REFERENCES matches(id) ON DELETE CASCADE,
+			from_status VARCHAR(20),
+			to_status VARCHAR(20) NOT NULL,
+			changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_application_status_history_match
+			ON application_status_history(match_id, created_at)`,
+		// Give matches from before the history its current status as a
+		// starting point
+		`INSERT INTO application_status_history (match_id, from_status, to_status, created_at)
+			SELECT m.id, NULL, COALESCE(m.application_status, 'active'), COALESCE(m.updated_at, m.created_at)
+			FROM matches m
+			WHERE m.status = 'matched' AND COALESCE(m.application_status, 'active') != 'active'
+			  AND NOT EXISTS (SELECT 1 FROM application_status_history h WHERE h.match_id = m.id)`,
+
+		// Job offers. Each revision is a new version; only the latest can
+		// still be pending or countered, older ones are superseded.
+		`CREATE TABLE IF NOT EXISTS offers (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			match_id UUID REFERENCES matches(id) ON DELETE CASCADE,
+			version INTEGER NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			salary_amount INTEGER NOT NULL,
+			salary_currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+			start_date DATE,
+			equity TEXT,
+			bonus INTEGER,
+			notes TEXT,
+			expires_at TIMESTAMP,
+			document_key TEXT,
+			document_name VARCHAR(255),
+			document_size BIGINT,
+			counter_salary INTEGER,
+			counter_start_date DATE,
+			counter_message TEXT,
+			response_reason TEXT,
+			responded_at TIMESTAMP,
+			created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(match_id, version)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_offers_expiry ON offers(expires_at) WHERE status = 'pending'`,
+
+		// Who unmatched and why
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS unmatched_by UUID REFERENCES users(id) ON DELETE SET NULL`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS unmatched_at TIMESTAMP`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS unmatch_reason TEXT`,
+
+		// Blocked users never see each other in their feeds or match again
+		`CREATE TABLE IF NOT EXISTS user_blocks (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			blocker_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			blocked_id UUID REFERENCES users(id) ON DELETE CASCADE,
+			match_id UUID REFERENCES matches(id) ON DELETE SET NULL,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(blocker_id, blocked_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_user_blocks_blocked ON user_blocks(blocked_id)`,
+
+		// Silent matches are nudged, then expire. Recruiters can override
+		// the default thresholds; NULL means the default.
+		`ALTER TABLE recruiter_profiles ADD COLUMN IF NOT EXISTS match_nudge_days INTEGER`,
+		`ALTER TABLE recruiter_profiles ADD COLUMN IF NOT EXISTS match_expiry_days INTEGER`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS nudged_at TIMESTAMP`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS expired_at TIMESTAMP`,
+		`ALTER TABLE matches ADD COLUMN IF NOT EXISTS restored_at TIMESTAMP`,
+
+		// Open chat connections across replicas, so a user goes offline as
+		// soon as their last one closes
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS presence_connections INTEGER NOT NULL DEFAULT 0`,
+	}
+
+	for i, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("migration v5 %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
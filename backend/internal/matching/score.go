@@ -0,0 +1,284 @@
+// Package matching scores how well a job seeker profile fits a job posting.
+// Everything here is pure so it can be exercised without a database.
+package matching
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Weights of each factor. They add up to 100 so the final score is a
+// percentage.
+const (
+	WeightSkills         = 40
+	WeightExperience     = 20
+	WeightWorkPreference = 15
+	WeightLocation       = 10
+	WeightSalary         = 15
+)
+
+// Score returns a 0-100 fit between a job seeker profile and a job.
+func Score(p *models.JobSeekerProfile, j *models.Job) int {
+	return Explain(p, j).Score
+}
+
+// Explain scores a profile against a job and reports how each factor
+// contributed. The factor contributions always add up to the score.
+func Explain(p *models.JobSeekerProfile, j *models.Job) *models.MatchExplanation {
+	e := &models.MatchExplanation{}
+	if j.ID != uuid.Nil {
+		jobID := j.ID
+		e.JobID = &jobID
+	}
+
+	e.Skills, e.MatchedSkills, e.MissingSkills = skillsFit(p, j)
+	e.Experience = experienceFit(p, j)
+	e.WorkPreference = workPreferenceFit(p, j)
+	e.Location = locationFit(p, j)
+	e.Salary = salaryFit(p, j)
+
+	total := e.Skills.Contribution + e.Experience.Contribution +
+		e.WorkPreference.Contribution + e.Location.Contribution + e.Salary.Contribution
+	e.Score = clamp(total, 0, 100)
+
+	return e
+}
+
+// BestScore scores a profile against several jobs and keeps the best fit.
+// It is used when a recruiter browses candidates without picking a job.
+func BestScore(p *models.JobSeekerProfile, jobs []models.Job) int {
+	if e := BestExplain(p, jobs); e != nil {
+		return e.Score
+	}
+	return 0
+}
+
+// BestExplain is Explain against whichever of the jobs fits best. It returns
+// nil when there are no jobs.
+func BestExplain(p *models.JobSeekerProfile, jobs []models.Job) *models.MatchExplanation {
+	var best *models.MatchExplanation
+	for i := range jobs {
+		if e := Explain(p, &jobs[i]); best == nil || e.Score > best.Score {
+			best = e
+		}
+	}
+	return best
+}
+
+// IncompatibleWorkPreference returns the work arrangement that can never
+// satisfy the given preference, or "" if every arrangement is acceptable.
+// Remote and onsite rule each other out; hybrid and any accept everything.
+func IncompatibleWorkPreference(wp models.WorkPreference) models.WorkPreference {
+	switch wp {
+	case models.WorkPreferenceRemote:
+		return models.WorkPreferenceOnsite
+	case models.WorkPreferenceOnsite:
+		return models.WorkPreferenceRemote
+	default:
+		return ""
+	}
+}
+
+func skillsFit(p *models.JobSeekerProfile, j *models.Job) (models.FitFactor, []string, []string) {
+	matched, missing := []string{}, []string{}
+
+	have := make(map[string]bool, len(p.Skills))
+	for _, s := range normalizeAll(p.Skills) {
+		have[s] = true
+	}
+
+	seen := make(map[string]bool, len(j.Skills))
+	for _, s := range j.Skills {
+		n := normalize(s)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		if have[n] {
+			matched = append(matched, strings.TrimSpace(s))
+		} else {
+			missing = append(missing, strings.TrimSpace(s))
+		}
+	}
+
+	required := len(matched) + len(missing)
+	if required == 0 {
+		return factor(WeightSkills, 1, models.FitUnknown, "The job lists no required skills"), matched, missing
+	}
+
+	ratio := float64(len(matched)) / float64(required)
+	detail := fmt.Sprintf("%d of %d required skills", len(matched), required)
+	return factor(WeightSkills, ratio, levelFor(ratio), detail), matched, missing
+}
+
+func experienceFit(p *models.JobSeekerProfile, j *models.Job) models.FitFactor {
+	years := p.YearsOfExperience
+	minYears, maxYears := j.MinYearsExp, j.MaxYearsExp
+
+	switch {
+	case years < minYears:
+		// Each missing year costs a quarter of the weight
+		gap := minYears - years
+		ratio := maxFloat(0, 1-0.25*float64(gap))
+		return factor(WeightExperience, ratio, levelFor(ratio),
+			fmt.Sprintf("%d years of experience, the job asks for at least %d", years, minYears))
+	case maxYears > 0 && years > maxYears:
+		// Overqualified candidates still fit, just less well
+		gap := years - maxYears
+		ratio := maxFloat(0.5, 1-0.1*float64(gap))
+		return factor(WeightExperience, ratio, levelFor(ratio),
+			fmt.Sprintf("%d years of experience, above the job's %d-%d year range", years, minYears, maxYears))
+	case maxYears > 0:
+		return factor(WeightExperience, 1, models.FitGood,
+			fmt.Sprintf("%d years of experience, within the job's %d-%d year range", years, minYears, maxYears))
+	default:
+		return factor(WeightExperience, 1, models.FitGood,
+			fmt.Sprintf("%d years of experience, the job asks for at least %d", years, minYears))
+	}
+}
+
+func workPreferenceFit(p *models.JobSeekerProfile, j *models.Job) models.FitFactor {
+	seeker, job := p.WorkPreference, j.WorkPreference
+	detail := fmt.Sprintf("Candidate prefers %s, job is %s", orAny(seeker), orAny(job))
+
+	switch {
+	case seeker == "" || job == "" || seeker == models.WorkPreferenceAny || job == models.WorkPreferenceAny || seeker == job:
+		return factor(WeightWorkPreference, 1, models.FitGood, detail)
+	case seeker == models.WorkPreferenceHybrid || job == models.WorkPreferenceHybrid:
+		// Hybrid sits halfway between remote and onsite
+		return factor(WeightWorkPreference, 0.5, models.FitPartial, detail)
+	default:
+		return factor(WeightWorkPreference, 0, models.FitPoor, detail)
+	}
+}
+
+func locationFit(p *models.JobSeekerProfile, j *models.Job) models.FitFactor {
+	if j.WorkPreference == models.WorkPreferenceRemote {
+		return factor(WeightLocation, 1, models.FitGood, "The job is remote")
+	}
+	if strings.TrimSpace(j.Location) == "" {
+		return factor(WeightLocation, 1, models.FitUnknown, "The job has no location")
+	}
+	if len(p.PreferredLocations) == 0 {
+		return factor(WeightLocation, 0.5, models.FitUnknown, "Candidate has no preferred locations")
+	}
+
+	jobLocation := normalize(j.Location)
+	for _, loc := range normalizeAll(p.PreferredLocations) {
+		if strings.Contains(jobLocation, loc) || strings.Contains(loc, jobLocation) {
+			return factor(WeightLocation, 1, models.FitGood, j.Location+" is a preferred location")
+		}
+	}
+
+	if p.OpenToRelocation {
+		return factor(WeightLocation, 0.6, models.FitPartial, j.Location+" is not preferred, but candidate is open to relocation")
+	}
+	return factor(WeightLocation, 0, models.FitPoor, j.Location+" is not a preferred location")
+}
+
+func salaryFit(p *models.JobSeekerProfile, j *models.Job) models.FitFactor {
+	seekerMin, seekerMax := band(p.ExpectedSalaryMin, p.ExpectedSalaryMax)
+	jobMin, jobMax := band(j.SalaryMin, j.SalaryMax)
+
+	// Nothing to compare against, so nothing conflicts
+	if seekerMax == 0 {
+		return factor(WeightSalary, 1, models.FitUnknown, "Candidate has no salary expectations")
+	}
+	if jobMax == 0 {
+		return factor(WeightSalary, 1, models.FitUnknown, "The job has no salary range")
+	}
+	if p.SalaryCurrency != "" && j.SalaryCurrency != "" && !strings.EqualFold(p.SalaryCurrency, j.SalaryCurrency) {
+		return factor(WeightSalary, 0.5, models.FitUnknown,
+			fmt.Sprintf("Salaries are in different currencies (%s vs %s)", p.SalaryCurrency, j.SalaryCurrency))
+	}
+
+	// Bands overlap
+	if jobMax >= seekerMin && jobMin <= seekerMax {
+		return factor(WeightSalary, 1, models.FitGood, "Salary ranges overlap")
+	}
+
+	// Job pays less than the seeker's floor
+	if jobMax < seekerMin {
+		shortfall := float64(seekerMin-jobMax) / float64(seekerMin)
+		ratio := maxFloat(0, 1-2*shortfall)
+		return factor(WeightSalary, ratio, levelFor(ratio),
+			fmt.Sprintf("The job pays up to %d, %d%% below the expected minimum", jobMax, int(math.Round(shortfall*100))))
+	}
+
+	// Job pays more than the seeker asked for - still a good fit
+	return factor(WeightSalary, 1, models.FitGood, "The job pays above the expected range")
+}
+
+// factor builds a FitFactor worth ratio of weight points, rounded.
+func factor(weight int, ratio float64, fit models.FitLevel, detail string) models.FitFactor {
+	return models.FitFactor{
+		Fit:          fit,
+		Contribution: int(math.Round(float64(weight) * ratio)),
+		MaxPoints:    weight,
+		Detail:       detail,
+	}
+}
+
+func levelFor(ratio float64) models.FitLevel {
+	switch {
+	case ratio >= 0.8:
+		return models.FitGood
+	case ratio >= 0.4:
+		return models.FitPartial
+	default:
+		return models.FitPoor
+	}
+}
+
+// band fills in a missing bound so a one-sided range still compares sensibly.
+func band(lo, hi int) (int, int) {
+	if hi == 0 {
+		hi = lo
+	}
+	if lo == 0 {
+		lo = hi
+	}
+	return lo, hi
+}
+
+func orAny(wp models.WorkPreference) models.WorkPreference {
+	if wp == "" {
+		return models.WorkPreferenceAny
+	}
+	return wp
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func normalizeAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if n := normalize(v); n != "" {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,298 @@
+package matching
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blowjobs-ai/backend/internal/models"
+)
+
+// baseProfile and baseJob fit each other perfectly; each case changes one
+// side to exercise a single factor.
+func baseProfile() *models.JobSeekerProfile {
+	return &models.JobSeekerProfile{
+		Skills:             []string{"Go", "SQL"},
+		YearsOfExperience:  4,
+		WorkPreference:     models.WorkPreferenceHybrid,
+		PreferredLocations: []string{"Berlin"},
+		ExpectedSalaryMin:  100000,
+		ExpectedSalaryMax:  120000,
+		SalaryCurrency:     "EUR",
+	}
+}
+
+func baseJob() *models.Job {
+	return &models.Job{
+		Skills:         []string{"go", "sql"},
+		MinYearsExp:    3,
+		MaxYearsExp:    6,
+		WorkPreference: models.WorkPreferenceHybrid,
+		Location:       "Berlin, Germany",
+		SalaryMin:      110000,
+		SalaryMax:      130000,
+		SalaryCurrency: "EUR",
+	}
+}
+
+func TestExplain(t *testing.T) {
+	skills := func(e *models.MatchExplanation) models.FitFactor { return e.Skills }
+	experience := func(e *models.MatchExplanation) models.FitFactor { return e.Experience }
+	workPreference := func(e *models.MatchExplanation) models.FitFactor { return e.WorkPreference }
+	location := func(e *models.MatchExplanation) models.FitFactor { return e.Location }
+	salary := func(e *models.MatchExplanation) models.FitFactor { return e.Salary }
+
+	tests := []struct {
+		name         string
+		profile      func(p *models.JobSeekerProfile)
+		job          func(j *models.Job)
+		factor       func(e *models.MatchExplanation) models.FitFactor
+		fit          models.FitLevel
+		contribution int
+	}{
+		{
+			name:         "all skills",
+			factor:       skills,
+			fit:          models.FitGood,
+			contribution: WeightSkills,
+		},
+		{
+			name:         "some skills missing",
+			job:          func(j *models.Job) { j.Skills = []string{"Go", "SQL", "Kubernetes", " go "} },
+			factor:       skills,
+			fit:          models.FitPartial,
+			contribution: 27, // 2 of 3
+		},
+		{
+			name:         "no skills in common",
+			job:          func(j *models.Job) { j.Skills = []string{"Rust"} },
+			factor:       skills,
+			fit:          models.FitPoor,
+			contribution: 0,
+		},
+		{
+			name:         "no required skills",
+			job:          func(j *models.Job) { j.Skills = nil },
+			factor:       skills,
+			fit:          models.FitUnknown,
+			contribution: WeightSkills,
+		},
+		{
+			name:         "years below the band",
+			profile:      func(p *models.JobSeekerProfile) { p.YearsOfExperience = 1 },
+			factor:       experience,
+			fit:          models.FitPartial,
+			contribution: 10, // 2 years short
+		},
+		{
+			name:         "years far below the band",
+			profile:      func(p *models.JobSeekerProfile) { p.YearsOfExperience = 0 },
+			job:          func(j *models.Job) { j.MinYearsExp = 8; j.MaxYearsExp = 10 },
+			factor:       experience,
+			fit:          models.FitPoor,
+			contribution: 0,
+		},
+		{
+			name:         "years inside the band",
+			factor:       experience,
+			fit:          models.FitGood,
+			contribution: WeightExperience,
+		},
+		{
+			name:         "years above the band",
+			profile:      func(p *models.JobSeekerProfile) { p.YearsOfExperience = 9 },
+			factor:       experience,
+			fit:          models.FitPartial,
+			contribution: 14, // 3 years over
+		},
+		{
+			name:         "years far above the band",
+			profile:      func(p *models.JobSeekerProfile) { p.YearsOfExperience = 25 },
+			factor:       experience,
+			fit:          models.FitPartial,
+			contribution: 10, // Never below half
+		},
+		{
+			name:         "no upper bound",
+			profile:      func(p *models.JobSeekerProfile) { p.YearsOfExperience = 25 },
+			job:          func(j *models.Job) { j.MaxYearsExp = 0 },
+			factor:       experience,
+			fit:          models.FitGood,
+			contribution: WeightExperience,
+		},
+		{
+			name: "remote wants remote",
+			profile: func(p *models.JobSeekerProfile) {
+				p.WorkPreference = models.WorkPreferenceRemote
+			},
+			job:          func(j *models.Job) { j.WorkPreference = models.WorkPreferenceRemote },
+			factor:       workPreference,
+			fit:          models.FitGood,
+			contribution: WeightWorkPreference,
+		},
+		{
+			name:         "hybrid job for an onsite candidate",
+			profile:      func(p *models.JobSeekerProfile) { p.WorkPreference = models.WorkPreferenceOnsite },
+			factor:       workPreference,
+			fit:          models.FitPartial,
+			contribution: 8,
+		},
+		{
+			name:         "onsite job for a remote candidate",
+			profile:      func(p *models.JobSeekerProfile) { p.WorkPreference = models.WorkPreferenceRemote },
+			job:          func(j *models.Job) { j.WorkPreference = models.WorkPreferenceOnsite },
+			factor:       workPreference,
+			fit:          models.FitPoor,
+			contribution: 0,
+		},
+		{
+			name:         "candidate open to any arrangement",
+			profile:      func(p *models.JobSeekerProfile) { p.WorkPreference = models.WorkPreferenceAny },
+			job:          func(j *models.Job) { j.WorkPreference = models.WorkPreferenceOnsite },
+			factor:       workPreference,
+			fit:          models.FitGood,
+			contribution: WeightWorkPreference,
+		},
+		{
+			name:         "preferred location",
+			factor:       location,
+			fit:          models.FitGood,
+			contribution: WeightLocation,
+		},
+		{
+			name: "remote job ignores location",
+			job: func(j *models.Job) {
+				j.WorkPreference = models.WorkPreferenceRemote
+				j.Location = "Lisbon"
+			},
+			factor:       location,
+			fit:          models.FitGood,
+			contribution: WeightLocation,
+		},
+		{
+			name: "open to relocation",
+			profile: func(p *models.JobSeekerProfile) {
+				p.OpenToRelocation = true
+			},
+			job:          func(j *models.Job) { j.Location = "Lisbon" },
+			factor:       location,
+			fit:          models.FitPartial,
+			contribution: 6,
+		},
+		{
+			name:         "not open to relocation",
+			job:          func(j *models.Job) { j.Location = "Lisbon" },
+			factor:       location,
+			fit:          models.FitPoor,
+			contribution: 0,
+		},
+		{
+			name:         "salary ranges overlap",
+			factor:       salary,
+			fit:          models.FitGood,
+			contribution: WeightSalary,
+		},
+		{
+			name:         "small salary shortfall",
+			job:          func(j *models.Job) { j.SalaryMin = 80000; j.SalaryMax = 90000 },
+			factor:       salary,
+			fit:          models.FitGood,
+			contribution: 12, // 10% short
+		},
+		{
+			name:         "large salary shortfall",
+			job:          func(j *models.Job) { j.SalaryMin = 40000; j.SalaryMax = 50000 },
+			factor:       salary,
+			fit:          models.FitPoor,
+			contribution: 0,
+		},
+		{
+			name:         "job pays above the range",
+			job:          func(j *models.Job) { j.SalaryMin = 150000; j.SalaryMax = 0 },
+			factor:       salary,
+			fit:          models.FitGood,
+			contribution: WeightSalary,
+		},
+		{
+			name:         "salary currency mismatch",
+			job:          func(j *models.Job) { j.SalaryCurrency = "usd" },
+			factor:       salary,
+			fit:          models.FitUnknown,
+			contribution: 8,
+		},
+		{
+			name:         "no salary expectations",
+			profile:      func(p *models.JobSeekerProfile) { p.ExpectedSalaryMin = 0; p.ExpectedSalaryMax = 0 },
+			factor:       salary,
+			fit:          models.FitUnknown,
+			contribution: WeightSalary,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, j := baseProfile(), baseJob()
+			if tt.profile != nil {
+				tt.profile(p)
+			}
+			if tt.job != nil {
+				tt.job(j)
+			}
+
+			e := Explain(p, j)
+			f := tt.factor(e)
+			if f.Fit != tt.fit || f.Contribution != tt.contribution {
+				t.Errorf("got %s for %d points (%s), want %s for %d", f.Fit, f.Contribution, f.Detail, tt.fit, tt.contribution)
+			}
+			if f.Contribution < 0 || f.Contribution > f.MaxPoints {
+				t.Errorf("contribution %d outside 0-%d", f.Contribution, f.MaxPoints)
+			}
+
+			sum := e.Skills.Contribution + e.Experience.Contribution + e.WorkPreference.Contribution +
+				e.Location.Contribution + e.Salary.Contribution
+			if sum != e.Score {
+				t.Errorf("contributions add up to %d, score is %d", sum, e.Score)
+			}
+			if score := Score(p, j); score != e.Score {
+				t.Errorf("Score = %d, Explain.Score = %d", score, e.Score)
+			}
+		})
+	}
+}
+
+func TestExplainSkills(t *testing.T) {
+	p := baseProfile()
+	j := baseJob()
+	j.Skills = []string{" Go ", "Kubernetes", "sql", "GO", "Terraform"}
+
+	e := Explain(p, j)
+	if want := []string{"Go", "sql"}; !reflect.DeepEqual(e.MatchedSkills, want) {
+		t.Errorf("MatchedSkills = %q, want %q", e.MatchedSkills, want)
+	}
+	if want := []string{"Kubernetes", "Terraform"}; !reflect.DeepEqual(e.MissingSkills, want) {
+		t.Errorf("MissingSkills = %q, want %q", e.MissingSkills, want)
+	}
+}
+
+func TestExplainPerfectFit(t *testing.T) {
+	if score := Score(baseProfile(), baseJob()); score != 100 {
+		t.Errorf("Score = %d, want 100", score)
+	}
+}
+
+func TestBestExplain(t *testing.T) {
+	if e := BestExplain(baseProfile(), nil); e != nil {
+		t.Errorf("BestExplain with no jobs = %+v, want nil", e)
+	}
+
+	poor := *baseJob()
+	poor.Skills = []string{"Rust"}
+	jobs := []models.Job{poor, *baseJob()}
+
+	e := BestExplain(baseProfile(), jobs)
+	if e == nil || e.Score != 100 {
+		t.Fatalf("BestExplain = %+v, want the perfect fit", e)
+	}
+	if score := BestScore(baseProfile(), jobs); score != e.Score {
+		t.Errorf("BestScore = %d, want %d", score, e.Score)
+	}
+}
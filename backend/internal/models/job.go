@@ -81,35 +81,39 @@ type CreateJobRequest struct {
 
 // JobCard is the simplified version shown when swiping
 type JobCard struct {
-	ID              uuid.UUID       `json:"id"`
-	Title           string          `json:"title"`
-	CompanyName     string          `json:"company_name"`
-	Location        string          `json:"location"`
-	WorkPreference  WorkPreference  `json:"work_preference"`
-	JobType         JobType         `json:"job_type"`
-	ExperienceLevel ExperienceLevel `json:"experience_level"`
-	Skills          []string        `json:"skills"`
-	SalaryRange     string          `json:"salary_range,omitempty"` // Formatted string if shown
-	Benefits        []string        `json:"benefits"`
-	IsFeatured      bool            `json:"is_featured"`
-	MatchScore      int             `json:"match_score"` // 0-100 based on profile match
+	ID              uuid.UUID         `json:"id"`
+	Title           string            `json:"title"`
+	CompanyName     string            `json:"company_name"`
+	Location        string            `json:"location"`
+	WorkPreference  WorkPreference    `json:"work_preference"`
+	JobType         JobType           `json:"job_type"`
+	ExperienceLevel ExperienceLevel   `json:"experience_level"`
+	Skills          []string          `json:"skills"`
+	SalaryRange     string            `json:"salary_range,omitempty"` // Formatted string if shown
+	Benefits        []string          `json:"benefits"`
+	IsFeatured      bool              `json:"is_featured"`
+	MatchScore      int               `json:"match_score"` // 0-100 based on profile match
+	Explanation     *MatchExplanation `json:"explanation,omitempty"`
+	SuperLikedYou   bool              `json:"super_liked_you,omitempty"` // The recruiter super-liked the viewer
 }
 
 // ProfileCard is the simplified job seeker profile shown to recruiters
 type ProfileCard struct {
-	ID                uuid.UUID       `json:"id"`
-	FirstName         string          `json:"first_name"`
-	Headline          string          `json:"headline"`
-	Summary           string          `json:"summary,omitempty"`
-	ExperienceLevel   ExperienceLevel `json:"experience_level"`
-	YearsOfExperience int             `json:"years_of_experience"`
-	Skills            []string        `json:"skills"`
-	WorkPreference    WorkPreference  `json:"work_preference"`
-	PreferredLocation string          `json:"preferred_location"`
-	ExpectedSalary    string          `json:"expected_salary,omitempty"`
-	Languages         []string        `json:"languages,omitempty"`
-	Certifications    []string        `json:"certifications,omitempty"`
-	OpenToRelocation  bool            `json:"open_to_relocation"`
-	MatchScore        int             `json:"match_score"` // 0-100 based on job requirements match
+	ID                uuid.UUID         `json:"id"`
+	FirstName         string            `json:"first_name"`
+	Headline          string            `json:"headline"`
+	Summary           string            `json:"summary,omitempty"`
+	ExperienceLevel   ExperienceLevel   `json:"experience_level"`
+	YearsOfExperience int               `json:"years_of_experience"`
+	Skills            []string          `json:"skills"`
+	WorkPreference    WorkPreference    `json:"work_preference"`
+	PreferredLocation string            `json:"preferred_location"`
+	ExpectedSalary    string            `json:"expected_salary,omitempty"`
+	Languages         []string          `json:"languages,omitempty"`
+	Certifications    []string          `json:"certifications,omitempty"`
+	OpenToRelocation  bool              `json:"open_to_relocation"`
+	MatchScore        int               `json:"match_score"` // 0-100 based on job requirements match
+	Explanation       *MatchExplanation `json:"explanation,omitempty"`
+	SuperLikedYou     bool              `json:"super_liked_you,omitempty"` // The candidate super-liked one of the viewer's jobs
 }
 
@@ -11,7 +11,7 @@ type SwipeDirection string
 const (
 	SwipeLeft  SwipeDirection = "left"  // Pass/Reject
 	SwipeRight SwipeDirection = "right" // Like/Interested
-	SwipeUp    SwipeDirection = "up"    // Super Like (premium feature)
+	SwipeUp    SwipeDirection = "up"    // Super Like, limited by a daily quota
 )
 
 type MatchStatus string
@@ -20,6 +20,7 @@ const (
 	MatchStatusPending   MatchStatus = "pending"   // One side swiped right
 	MatchStatusMatched   MatchStatus = "matched"   // Both sides swiped right
 	MatchStatusUnmatched MatchStatus = "unmatched" // One side unmatched
+	MatchStatusExpired   MatchStatus = "expired"   // Archived after a long silence, can be restored
 )
 
 type InterviewStatus string
@@ -44,6 +45,39 @@ const (
 	ApplicationStatusHired     ApplicationStatus = "hired"
 )
 
+// applicationTransitions lists the statuses each status can move to.
+// hired, rejected and withdrawn end the process.
+var applicationTransitions = map[ApplicationStatus][]ApplicationStatus{
+	ApplicationStatusActive:    {ApplicationStatusReviewing, ApplicationStatusInterview, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusReviewing: {ApplicationStatusInterview, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusInterview: {ApplicationStatusReviewing, ApplicationStatusOffered, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusOffered:   {ApplicationStatusHired, ApplicationStatusRejected, ApplicationStatusWithdrawn},
+	ApplicationStatusRejected:  {},
+	ApplicationStatusWithdrawn: {},
+	ApplicationStatusHired:     {},
+}
+
+// IsValid reports whether s is a known application status
+func (s ApplicationStatus) IsValid() bool {
+	_, ok := applicationTransitions[s]
+	return ok
+}
+
+// NextStatuses returns the statuses s can move to
+func (s ApplicationStatus) NextStatuses() []ApplicationStatus {
+	return append([]ApplicationStatus{}, applicationTransitions[s]...)
+}
+
+// CanTransitionTo reports whether a match in status s may move to next
+func (s ApplicationStatus) CanTransitionTo(next ApplicationStatus) bool {
+	for _, allowed := range applicationTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 // Swipe records a user's swipe action
 type Swipe struct {
 	ID           uuid.UUID      `json:"id"`
@@ -51,6 +85,7 @@ type Swipe struct {
 	SwipedID     uuid.UUID      `json:"swiped_id"`     // Job ID (if job seeker) or Profile ID (if recruiter)
 	SwipeType    string         `json:"swipe_type"`    // "job" or "profile"
 	Direction    SwipeDirection `json:"direction"`
+	JobID        *uuid.UUID     `json:"job_id,omitempty"` // Job a recruiter decided for
 	CreatedAt    time.Time      `json:"created_at"`
 }
 
@@ -66,8 +101,9 @@ type Match struct {
 	JobSeekerSwipedAt time.Time         `json:"job_seeker_swiped_at"`
 	RecruiterSwipedAt *time.Time        `json:"recruiter_swiped_at,omitempty"`
 	MatchedAt         *time.Time        `json:"matched_at,omitempty"`
+	IsSuperLike       bool              `json:"is_super_like"` // Started from a super like
 	LastMessageAt     *time.Time        `json:"last_message_at,omitempty"`
-	UnreadCount       int               `json:"unread_count"`
+	UnreadCount       int               `json:"unread_count"` // For the user asking
 	CreatedAt         time.Time         `json:"created_at"`
 	UpdatedAt         time.Time         `json:"updated_at"`
 }
@@ -75,11 +111,43 @@ type Match struct {
 // MatchWithDetails includes related info for display
 type MatchWithDetails struct {
 	Match
-	Job             JobCard    `json:"job"`
-	JobSeekerName   string     `json:"job_seeker_name"`
-	RecruiterName   string     `json:"recruiter_name"`
-	CompanyName     string     `json:"company_name"`
-	LastMessage     *Message   `json:"last_message,omitempty"`
+	Job                 JobCard           `json:"job"`
+	JobSeekerName       string            `json:"job_seeker_name"`
+	RecruiterName       string            `json:"recruiter_name"`
+	CompanyName         string            `json:"company_name"`
+	LastMessage         *Message          `json:"last_message,omitempty"`
+	Explanation         *MatchExplanation `json:"explanation,omitempty"`
+	CounterpartPresence *Presence         `json:"counterpart_presence,omitempty"` // nil if they hide it
+}
+
+type FitLevel string
+
+const (
+	FitGood    FitLevel = "good"
+	FitPartial FitLevel = "partial"
+	FitPoor    FitLevel = "poor"
+	FitUnknown FitLevel = "unknown" // One side didn't provide the data
+)
+
+// FitFactor is one component of a match score
+type FitFactor struct {
+	Fit          FitLevel `json:"fit"`
+	Contribution int      `json:"contribution"` // Points this factor added to the score
+	MaxPoints    int      `json:"max_points"`
+	Detail       string   `json:"detail"`
+}
+
+// MatchExplanation answers "why this match?" for a scored card or match
+type MatchExplanation struct {
+	Score          int        `json:"score"`
+	JobID          *uuid.UUID `json:"job_id,omitempty"` // Job the candidate was scored against
+	MatchedSkills  []string   `json:"matched_skills"`
+	MissingSkills  []string   `json:"missing_skills"` // Required by the job but not on the profile
+	Skills         FitFactor  `json:"skills"`
+	Experience     FitFactor  `json:"experience"`
+	WorkPreference FitFactor  `json:"work_preference"`
+	Location       FitFactor  `json:"location"`
+	Salary         FitFactor  `json:"salary"`
 }
 
 // Interview represents a scheduled interview
@@ -99,6 +167,20 @@ type Interview struct {
 	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
+// TimelineEntry is one event in a match's hiring pipeline
+type TimelineEntry struct {
+	Type        string             `json:"type"` // matched, status_change, interview_scheduled, interview_completed or interview_cancelled
+	At          time.Time          `json:"at"`
+	FromStatus  *ApplicationStatus `json:"from_status,omitempty"`
+	ToStatus    *ApplicationStatus `json:"to_status,omitempty"`
+	ActorID     *uuid.UUID         `json:"actor_id,omitempty"`
+	ActorName   string             `json:"actor_name,omitempty"`
+	Reason      string             `json:"reason,omitempty"`
+	InterviewID *uuid.UUID         `json:"interview_id,omitempty"`
+	ScheduledAt *time.Time         `json:"scheduled_at,omitempty"`
+	Result      string             `json:"result,omitempty"`
+}
+
 // CreateInterviewRequest for scheduling an interview
 type CreateInterviewRequest struct {
 	MatchID      uuid.UUID `json:"match_id" binding:"required"`
@@ -113,6 +195,7 @@ type CreateInterviewRequest struct {
 type SwipeRequest struct {
 	TargetID  uuid.UUID      `json:"target_id" binding:"required"`
 	Direction SwipeDirection `json:"direction" binding:"required"`
+	JobID     *uuid.UUID     `json:"job_id,omitempty"` // Recruiters only: the job this decision is for
 }
 
 // MatchResponse returned when a match occurs
@@ -0,0 +1,71 @@
+package models
+
+import "testing"
+
+func TestApplicationStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to ApplicationStatus
+		allowed  bool
+	}{
+		{ApplicationStatusActive, ApplicationStatusReviewing, true},
+		{ApplicationStatusActive, ApplicationStatusInterview, true},
+		{ApplicationStatusActive, ApplicationStatusOffered, true},
+		{ApplicationStatusActive, ApplicationStatusRejected, true},
+		{ApplicationStatusActive, ApplicationStatusWithdrawn, true},
+		{ApplicationStatusReviewing, ApplicationStatusInterview, true},
+		{ApplicationStatusInterview, ApplicationStatusReviewing, true},
+		{ApplicationStatusInterview, ApplicationStatusOffered, true},
+		{ApplicationStatusOffered, ApplicationStatusHired, true},
+		{ApplicationStatusOffered, ApplicationStatusRejected, true},
+		{ApplicationStatusOffered, ApplicationStatusWithdrawn, true},
+
+		// Hiring needs an offer first
+		{ApplicationStatusActive, ApplicationStatusHired, false},
+		{ApplicationStatusInterview, ApplicationStatusHired, false},
+		// No going back to the start
+		{ApplicationStatusReviewing, ApplicationStatusActive, false},
+		{ApplicationStatusOffered, ApplicationStatusInterview, false},
+		// Final statuses stay final
+		{ApplicationStatusHired, ApplicationStatusActive, false},
+		{ApplicationStatusHired, ApplicationStatusRejected, false},
+		{ApplicationStatusRejected, ApplicationStatusReviewing, false},
+		{ApplicationStatusWithdrawn, ApplicationStatusActive, false},
+		// Staying put isn't a transition
+		{ApplicationStatusReviewing, ApplicationStatusReviewing, false},
+		// Unknown statuses go nowhere
+		{ApplicationStatus("archived"), ApplicationStatusActive, false},
+		{ApplicationStatusActive, ApplicationStatus("archived"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.allowed {
+				t.Errorf("CanTransitionTo = %v, want %v", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestApplicationStatusTransitionTargetsAreValid(t *testing.T) {
+	for from, targets := range applicationTransitions {
+		if !from.IsValid() {
+			t.Errorf("%s is not valid", from)
+		}
+		for _, to := range targets {
+			if !to.IsValid() {
+				t.Errorf("%s can move to unknown status %s", from, to)
+			}
+		}
+	}
+	if ApplicationStatus("archived").IsValid() {
+		t.Error("unknown status is valid")
+	}
+}
+
+func TestNextStatusesIsACopy(t *testing.T) {
+	next := ApplicationStatusActive.NextStatuses()
+	next[0] = ApplicationStatusHired
+	if ApplicationStatusActive.CanTransitionTo(ApplicationStatusHired) {
+		t.Error("changing NextStatuses changed the state machine")
+	}
+}
@@ -13,18 +13,44 @@ const (
 	MessageTypeSystem     MessageType = "system"     // System notifications
 	MessageTypeInterview  MessageType = "interview"  // Interview scheduled/updated
 	MessageTypeStatus     MessageType = "status"     // Application status update
+	MessageTypeAttachment MessageType = "attachment" // File shared in chat; Content is the caption
 )
 
 // Message represents a chat message between matched users
 type Message struct {
-	ID        uuid.UUID   `json:"id"`
-	MatchID   uuid.UUID   `json:"match_id"`
-	SenderID  uuid.UUID   `json:"sender_id"`
-	Type      MessageType `json:"type"`
-	Content   string      `json:"content"`
-	IsRead    bool        `json:"is_read"`
-	ReadAt    *time.Time  `json:"read_at,omitempty"`
-	CreatedAt time.Time   `json:"created_at"`
+	ID         uuid.UUID   `json:"id"`
+	MatchID    uuid.UUID   `json:"match_id"`
+	SenderID   uuid.UUID   `json:"sender_id"`
+	Type       MessageType `json:"type"`
+	Content    string      `json:"content"`
+	IsRead     bool        `json:"is_read"`
+	ReadAt     *time.Time  `json:"read_at,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	EditedAt   *time.Time  `json:"edited_at,omitempty"`
+	DeletedAt  *time.Time  `json:"deleted_at,omitempty"` // Tombstone: Content is empty
+	Attachment *Attachment `json:"attachment,omitempty"`
+}
+
+// Attachment is a file shared in a conversation. URL downloads it and only
+// works for the two participants.
+type Attachment struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+}
+
+// MessageTemplate is a recruiter's reusable message. Content may contain
+// placeholders such as {{first_name}}, filled in per match when sent.
+type MessageTemplate struct {
+	ID            uuid.UUID          `json:"id"`
+	RecruiterID   uuid.UUID          `json:"recruiter_id"`
+	Name          string             `json:"name"`
+	Content       string             `json:"content"`
+	StatusTrigger *ApplicationStatus `json:"status_trigger,omitempty"` // Sent automatically on this status change
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
 }
 
 // MessageWithSender includes sender info
@@ -34,6 +60,19 @@ type MessageWithSender struct {
 	IsMine     bool   `json:"is_mine"` // True if current user sent it
 }
 
+// MessageSearchResult is a message matching a search. Snippet is escaped
+// HTML with the matched terms wrapped in <mark>.
+type MessageSearchResult struct {
+	MessageID  uuid.UUID `json:"message_id"`
+	MatchID    uuid.UUID `json:"match_id"`
+	SenderID   uuid.UUID `json:"sender_id"`
+	SenderName string    `json:"sender_name"`
+	IsMine     bool      `json:"is_mine"`
+	JobTitle   string    `json:"job_title"`
+	Snippet    string    `json:"snippet"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 // SendMessageRequest for sending a new message
 type SendMessageRequest struct {
 	MatchID uuid.UUID `json:"match_id" binding:"required"`
@@ -42,14 +81,15 @@ type SendMessageRequest struct {
 
 // Conversation represents a chat thread
 type Conversation struct {
-	MatchID       uuid.UUID          `json:"match_id"`
-	OtherUserName string             `json:"other_user_name"`
-	JobTitle      string             `json:"job_title"`
-	CompanyName   string             `json:"company_name"`
-	LastMessage   *Message           `json:"last_message,omitempty"`
-	UnreadCount   int                `json:"unread_count"`
-	Status        ApplicationStatus  `json:"status"`
-	UpdatedAt     time.Time          `json:"updated_at"`
+	MatchID           uuid.UUID         `json:"match_id"`
+	OtherUserName     string            `json:"other_user_name"`
+	JobTitle          string            `json:"job_title"`
+	CompanyName       string            `json:"company_name"`
+	LastMessage       *Message          `json:"last_message,omitempty"`
+	UnreadCount       int               `json:"unread_count"`
+	Status            ApplicationStatus `json:"status"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	OtherUserPresence *Presence         `json:"other_user_presence,omitempty"` // nil if they hide it
 }
 
 // WebSocket message types
@@ -68,6 +108,29 @@ type WSTypingIndicator struct {
 	IsTyping bool   `json:"is_typing"`
 }
 
+// WSReadReceipt tells a sender how far the other side has read
+type WSReadReceipt struct {
+	MatchID           string    `json:"match_id"`
+	ReaderID          string    `json:"reader_id"`
+	LastReadMessageID string    `json:"last_read_message_id"`
+	ReadAt            time.Time `json:"read_at"`
+}
+
+// WSMessageUpdated tells the other side a message was edited
+type WSMessageUpdated struct {
+	MatchID   string    `json:"match_id"`
+	MessageID string    `json:"message_id"`
+	Content   string    `json:"content"`
+	EditedAt  time.Time `json:"edited_at"`
+}
+
+// WSMessageDeleted tells the other side a message was deleted
+type WSMessageDeleted struct {
+	MatchID   string    `json:"match_id"`
+	MessageID string    `json:"message_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
 type WSNotification struct {
 	Type    string `json:"type"` // match, message, interview, status
 	Title   string `json:"title"`
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OfferStatus string
+
+const (
+	OfferStatusPending    OfferStatus = "pending"   // Waiting for the candidate
+	OfferStatusCountered  OfferStatus = "countered" // Candidate proposed other terms
+	OfferStatusAccepted   OfferStatus = "accepted"
+	OfferStatusDeclined   OfferStatus = "declined"
+	OfferStatusExpired    OfferStatus = "expired"    // Not answered before expires_at
+	OfferStatusSuperseded OfferStatus = "superseded" // Replaced by a newer version
+	OfferStatusRescinded  OfferStatus = "rescinded"  // The match moved on, e.g. the recruiter rejected the candidate
+)
+
+// Offer is one version of a job offer made on a match
+type Offer struct {
+	ID               uuid.UUID      `json:"id"`
+	MatchID          uuid.UUID      `json:"match_id"`
+	Version          int            `json:"version"`
+	Status           OfferStatus    `json:"status"`
+	SalaryAmount     int            `json:"salary_amount"`
+	SalaryCurrency   string         `json:"salary_currency"`
+	StartDate        *time.Time     `json:"start_date,omitempty"`
+	Equity           string         `json:"equity,omitempty"`
+	Bonus            *int           `json:"bonus,omitempty"`
+	Notes            string         `json:"notes,omitempty"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty"`
+	Document         *OfferDocument `json:"document,omitempty"`
+	CounterSalary    *int           `json:"counter_salary,omitempty"`
+	CounterStartDate *time.Time     `json:"counter_start_date,omitempty"`
+	CounterMessage   string         `json:"counter_message,omitempty"`
+	ResponseReason   string         `json:"response_reason,omitempty"`
+	RespondedAt      *time.Time     `json:"responded_at,omitempty"`
+	CreatedBy        *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// OfferDocument is the offer letter attached to an offer
+type OfferDocument struct {
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url"`
+}
+
+// CreateOfferRequest makes an offer, or a new version of the current one
+type CreateOfferRequest struct {
+	SalaryAmount   int        `json:"salary_amount" binding:"required,gt=0"`
+	SalaryCurrency string     `json:"salary_currency"`
+	StartDate      string     `json:"start_date"` // YYYY-MM-DD
+	Equity         string     `json:"equity"`
+	Bonus          *int       `json:"bonus"`
+	Notes          string     `json:"notes"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// CounterOfferRequest is the candidate's answer with other terms
+type CounterOfferRequest struct {
+	SalaryAmount *int   `json:"salary_amount"`
+	StartDate    string `json:"start_date"` // YYYY-MM-DD
+	Message      string `json:"message"`
+}
@@ -129,3 +129,10 @@ type CreateRecruiterProfileRequest struct {
 	Bio            string `json:"bio"`
 }
 
+// MatchSettings are a recruiter's thresholds for silent matches, in days.
+// nil uses the server default; 0 turns the step off.
+type MatchSettings struct {
+	NudgeAfterDays  *int `json:"nudge_after_days"`
+	ExpireAfterDays *int `json:"expire_after_days"`
+}
+
@@ -30,15 +30,26 @@ type User struct {
 	TotalMatches    int       `json:"total_matches"`
 	Badges          []string  `json:"badges"`
 	LastSwipeDate   *time.Time `json:"last_swipe_date,omitempty"`
+
+	// Privacy
+	HidePresence bool `json:"hide_presence"`
+}
+
+// Presence is whether a user is connected right now, or when they last were
+type Presence struct {
+	Online     bool       `json:"online"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
 }
 
 type UserStats struct {
-	TodaySwipes      int `json:"today_swipes"`
-	WeeklySwipes     int `json:"weekly_swipes"`
-	CurrentStreak    int `json:"current_streak"`
-	TotalMatches     int `json:"total_matches"`
-	PendingMessages  int `json:"pending_messages"`
-	ProfileViews     int `json:"profile_views"`
+	TodaySwipes         int `json:"today_swipes"`
+	WeeklySwipes        int `json:"weekly_swipes"`
+	CurrentStreak       int `json:"current_streak"`
+	TotalMatches        int `json:"total_matches"`
+	PendingMessages     int `json:"pending_messages"`
+	ProfileViews        int `json:"profile_views"`
+	SuperLikesRemaining int `json:"super_likes_remaining"`
+	SuperLikesQuota     int `json:"super_likes_quota"` // Super likes per day
 }
 
 // CreateUserRequest is used for user registration
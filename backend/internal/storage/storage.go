@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no file is stored under a key
+var ErrNotFound = errors.New("storage: file not found")
+
+// Storage keeps uploaded files under slash-separated keys such as
+// "cv/<id>.pdf", so handlers don't care where the bytes end up
+type Storage interface {
+	// Put stores everything read from r under key, replacing any file
+	// already there, and returns the number of bytes written
+	Put(key string, r io.Reader) (int64, error)
+
+	// Open returns the file stored under key, or ErrNotFound
+	Open(key string) (io.ReadCloser, error)
+
+	// Delete removes the file under key. Deleting a missing file is not an
+	// error.
+	Delete(key string) error
+}
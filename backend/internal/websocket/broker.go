@@ -0,0 +1,36 @@
+package websocket
+
+import "github.com/google/uuid"
+
+// Broker carries events between API replicas. Every event published on any
+// replica is handed to the deliver function subscribed on every replica,
+// which passes it on to that replica's own connections.
+type Broker interface {
+	Publish(userIDs []uuid.UUID, data []byte) error
+	Subscribe(deliver func(userIDs []uuid.UUID, data []byte))
+	Close() error
+}
+
+// LocalBroker delivers events in-process, for a single replica
+type LocalBroker struct {
+	deliver func(userIDs []uuid.UUID, data []byte)
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(userIDs []uuid.UUID, data []byte) error {
+	if b.deliver != nil {
+		b.deliver(userIDs, data)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Subscribe(deliver func(userIDs []uuid.UUID, data []byte)) {
+	b.deliver = deliver
+}
+
+func (b *LocalBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// maxNotifyPayload keeps NOTIFY payloads under Postgres' 8000 byte limit.
+// Larger events are stored in hub_payloads and only their ID is sent.
+const maxNotifyPayload = 7900
+
+// hubPayloadRetention is how long oversized payloads are kept for replicas
+// to fetch
+const hubPayloadRetention = 5 * time.Minute
+
+// PostgresBroker shares events between replicas through Postgres
+// LISTEN/NOTIFY on a single channel
+type PostgresBroker struct {
+	db       *sql.DB
+	listener *pq.Listener
+	channel  string
+	done     chan struct{}
+}
+
+// brokerEnvelope is the NOTIFY payload
+type brokerEnvelope struct {
+	UserIDs []uuid.UUID     `json:"user_ids,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Ref     int64           `json:"ref,omitempty"` // hub_payloads row holding an oversized envelope
+}
+
+func NewPostgresBroker(db *sql.DB, databaseURL, channel string) (*PostgresBroker, error) {
+	listener := pq.NewListener(databaseURL, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Hub listener error: %v", err)
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &PostgresBroker{
+		db:       db,
+		listener: listener,
+		channel:  channel,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+func (b *PostgresBroker) Publish(userIDs []uuid.UUID, data []byte) error {
+	payload, err := json.Marshal(brokerEnvelope{UserIDs: userIDs, Data: data})
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxNotifyPayload {
+		var ref int64
+		if err := b.db.QueryRow(`INSERT INTO hub_payloads (payload) VALUES ($1) RETURNING id`, payload).Scan(&ref); err != nil {
+			return err
+		}
+		b.db.Exec(`DELETE FROM hub_payloads WHERE created_at < $1`, time.Now().Add(-hubPayloadRetention))
+
+		if payload, err = json.Marshal(brokerEnvelope{Ref: ref}); err != nil {
+			return err
+		}
+	}
+
+	_, err = b.db.Exec(`SELECT pg_notify($1, $2)`, b.channel, string(payload))
+	return err
+}
+
+func (b *PostgresBroker) Subscribe(deliver func(userIDs []uuid.UUID, data []byte)) {
+	go func() {
+		// Ping now and then so a dead connection is noticed and re-established
+		ping := time.NewTicker(90 * time.Second)
+		defer ping.Stop()
+
+		for {
+			select {
+			case n, ok := <-b.listener.Notify:
+				if !ok {
+					return
+				}
+				if n == nil {
+					// Sent after a reconnect; anything published meanwhile is lost
+					log.Printf("Hub listener reconnected")
+					continue
+				}
+				b.dispatch(n.Extra, deliver)
+
+			case <-ping.C:
+				go b.listener.Ping()
+
+			case <-b.done:
+				return
+			}
+		}
+	}()
+}
+
+func (b *PostgresBroker) dispatch(payload string, deliver func(userIDs []uuid.UUID, data []byte)) {
+	var envelope brokerEnvelope
+	if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+		log.Printf("Hub listener: bad payload: %v", err)
+		return
+	}
+
+	if ref := envelope.Ref; ref != 0 {
+		var stored []byte
+		if err := b.db.QueryRow(`SELECT payload FROM hub_payloads WHERE id = $1`, ref).Scan(&stored); err != nil {
+			log.Printf("Hub listener: payload %d not found: %v", ref, err)
+			return
+		}
+		envelope = brokerEnvelope{}
+		if err := json.Unmarshal(stored, &envelope); err != nil {
+			log.Printf("Hub listener: bad stored payload %d: %v", ref, err)
+			return
+		}
+	}
+
+	deliver(envelope.UserIDs, envelope.Data)
+}
+
+func (b *PostgresBroker) Close() error {
+	close(b.done)
+	return b.listener.Close()
+}
@@ -19,13 +19,17 @@ const (
 	// Send pings to peer with this period (must be less than pongWait)
 	pingPeriod = (pongWait * 9) / 10
 
+	// PingPeriod is the heartbeat interval, for transports other than
+	// WebSocket that keep their own connection alive
+	PingPeriod = pingPeriod
+
 	// Maximum message size allowed from peer
 	maxMessageSize = 4096
 )
 
 type Client struct {
 	Hub      *Hub
-	Conn     *websocket.Conn
+	Conn     *websocket.Conn // nil for clients that aren't WebSocket connections
 	Send     chan []byte
 	UserID   uuid.UUID
 	UserType string
@@ -127,7 +131,8 @@ func (c *Client) WritePump() {
 	}
 }
 
-// SendMessage sends a message to this client
+// SendMessage sends a message to this client only. It is dropped if the
+// client has already disconnected.
 func (c *Client) SendMessage(msgType string, payload interface{}) error {
 	msg := OutgoingMessage{
 		Type:    msgType,
@@ -137,7 +142,7 @@ func (c *Client) SendMessage(msgType string, payload interface{}) error {
 	if err != nil {
 		return err
 	}
-	c.Send <- data
+	c.Hub.deliver([]*Client{c}, data)
 	return nil
 }
 
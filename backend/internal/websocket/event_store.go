@@ -0,0 +1,82 @@
+package websocket
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a persisted hub message. Seq increases by one for every event a
+// user receives, so a client can ask for everything after the last one it
+// saw.
+type Event struct {
+	Seq       int64           `json:"seq"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventStore persists events so clients that were offline can catch up
+type EventStore interface {
+	Append(userID uuid.UUID, eventType string, payload json.RawMessage) (*Event, error)
+	Since(userID uuid.UUID, seq int64, limit int) ([]Event, error)
+}
+
+// PostgresEventStore keeps events in user_events, numbered by a per-user
+// counter on users.event_seq
+type PostgresEventStore struct {
+	db *sql.DB
+}
+
+func NewPostgresEventStore(db *sql.DB) *PostgresEventStore {
+	return &PostgresEventStore{db: db}
+}
+
+func (s *PostgresEventStore) Append(userID uuid.UUID, eventType string, payload json.RawMessage) (*Event, error) {
+	if len(payload) == 0 {
+		payload = json.RawMessage(`{}`)
+	}
+
+	// Bumping the counter locks the user's row, so sequence numbers are
+	// handed out in commit order without gaps
+	event := &Event{Type: eventType, Payload: payload}
+	err := s.db.QueryRow(`
+		WITH next AS (
+			UPDATE users SET event_seq = COALESCE(event_seq, 0) + 1 WHERE id = $1 RETURNING event_seq
+		)
+		INSERT INTO user_events (user_id, seq, type, payload)
+		SELECT $1, event_seq, $2, $3 FROM next
+		RETURNING seq, created_at
+	`, userID, eventType, []byte(payload)).Scan(&event.Seq, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *PostgresEventStore) Since(userID uuid.UUID, seq int64, limit int) ([]Event, error) {
+	rows, err := s.db.Query(`
+		SELECT seq, type, payload, created_at FROM user_events
+		WHERE user_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`, userID, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.Seq, &e.Type, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
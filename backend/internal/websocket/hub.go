@@ -9,121 +9,183 @@ import (
 )
 
 type Hub struct {
-	// Registered clients by user ID
-	clients    map[uuid.UUID]*Client
+	// Registered clients by user ID; a user has one client per open
+	// connection (phone, browser tab, ...)
+	clients    map[uuid.UUID]map[*Client]struct{}
 	clientsMux sync.RWMutex
 
 	// Inbound messages from clients
 	broadcast chan []byte
 
-	// Register requests from clients
-	register chan *Client
-
 	// Unregister requests from clients
 	unregister chan *Client
+
+	// Carries events to the replicas the recipients are connected to
+	broker Broker
+
+	// Persists events for replay; nil keeps them in memory only
+	events EventStore
 }
 
+// NewHub returns a hub that only reaches clients connected to this process
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[uuid.UUID]*Client),
+	return NewHubWithBroker(NewLocalBroker())
+}
+
+// NewHubWithBroker returns a hub whose events go through broker, so users
+// connected to any replica receive them
+func NewHubWithBroker(broker Broker) *Hub {
+	h := &Hub{
+		clients:    make(map[uuid.UUID]map[*Client]struct{}),
 		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		broker:     broker,
 	}
+	broker.Subscribe(h.deliverLocal)
+	return h
 }
 
 func (h *Hub) Run() {
 	for {
 		select {
-		case client := <-h.register:
-			h.clientsMux.Lock()
-			h.clients[client.UserID] = client
-			h.clientsMux.Unlock()
-			log.Printf("Client registered: %s", client.UserID)
-
 		case client := <-h.unregister:
-			h.clientsMux.Lock()
-			if _, ok := h.clients[client.UserID]; ok {
-				delete(h.clients, client.UserID)
-				close(client.Send)
-			}
-			h.clientsMux.Unlock()
+			h.removeClient(client)
 			log.Printf("Client unregistered: %s", client.UserID)
 
 		case message := <-h.broadcast:
 			h.clientsMux.RLock()
-			for _, client := range h.clients {
-				select {
-				case client.Send <- message:
-				default:
-					close(client.Send)
-					delete(h.clients, client.UserID)
+			var all []*Client
+			for _, clients := range h.clients {
+				for client := range clients {
+					all = append(all, client)
 				}
 			}
 			h.clientsMux.RUnlock()
+			h.deliver(all, message)
 		}
 	}
 }
 
-// SendToUser sends a message to a specific user
+// SetEventStore makes the hub persist every event it sends, numbered per
+// user, so clients can replay what they missed
+func (h *Hub) SetEventStore(events EventStore) {
+	h.events = events
+}
+
+// SendToUser sends a message to every connection of a user
 func (h *Hub) SendToUser(userID uuid.UUID, message interface{}) error {
+	return h.SendToUsers([]uuid.UUID{userID}, message)
+}
+
+// SendToUsers sends a message to every connection of multiple users. With
+// an event store, the message is persisted for each user first and sent
+// with its sequence number.
+func (h *Hub) SendToUsers(userIDs []uuid.UUID, message interface{}) error {
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
+	if h.events == nil {
+		return h.broker.Publish(userIDs, data)
+	}
 
-	h.clientsMux.RLock()
-	client, ok := h.clients[userID]
-	h.clientsMux.RUnlock()
+	var msg struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
 
-	if ok {
-		select {
-		case client.Send <- data:
-		default:
-			h.clientsMux.Lock()
-			close(client.Send)
-			delete(h.clients, userID)
-			h.clientsMux.Unlock()
+	for _, userID := range userIDs {
+		event, err := h.events.Append(userID, msg.Type, msg.Payload)
+		if err != nil {
+			// Still deliver it live; it just can't be replayed
+			log.Printf("Failed to store %s event for %s: %v", msg.Type, userID, err)
+			h.broker.Publish([]uuid.UUID{userID}, data)
+			continue
 		}
-	}
 
+		eventData, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := h.broker.Publish([]uuid.UUID{userID}, eventData); err != nil {
+			log.Printf("Failed to publish %s event for %s: %v", msg.Type, userID, err)
+		}
+	}
 	return nil
 }
 
-// SendToUsers sends a message to multiple users
-func (h *Hub) SendToUsers(userIDs []uuid.UUID, message interface{}) error {
+// SendTransient sends a message that isn't worth replaying, such as a
+// typing indicator. It carries no sequence number.
+func (h *Hub) SendTransient(userID uuid.UUID, message interface{}) error {
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
+	return h.broker.Publish([]uuid.UUID{userID}, data)
+}
 
-	h.clientsMux.RLock()
-	defer h.clientsMux.RUnlock()
+// EventsSince returns up to limit of the user's events after seq
+func (h *Hub) EventsSince(userID uuid.UUID, seq int64, limit int) ([]Event, error) {
+	if h.events == nil {
+		return []Event{}, nil
+	}
+	return h.events.Since(userID, seq, limit)
+}
 
+// Replay sends a client up to limit of the events its user received after
+// seq and returns them. Events published while replaying may arrive twice;
+// clients skip sequence numbers they have already seen.
+func (h *Hub) Replay(client *Client, seq int64, limit int) ([]Event, error) {
+	events, err := h.EventsSince(client.UserID, seq, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		h.deliver([]*Client{client}, data)
+	}
+	return events, nil
+}
+
+// deliverLocal passes a published event to the recipients' connections on
+// this replica
+func (h *Hub) deliverLocal(userIDs []uuid.UUID, data []byte) {
+	h.clientsMux.RLock()
+	var targets []*Client
 	for _, userID := range userIDs {
-		if client, ok := h.clients[userID]; ok {
-			select {
-			case client.Send <- data:
-			default:
-				// Client buffer is full, skip
-			}
+		for client := range h.clients[userID] {
+			targets = append(targets, client)
 		}
 	}
+	h.clientsMux.RUnlock()
 
-	return nil
+	h.deliver(targets, data)
 }
 
-// IsOnline checks if a user is currently connected
+// IsOnline checks if a user has at least one open connection to this replica
 func (h *Hub) IsOnline(userID uuid.UUID) bool {
 	h.clientsMux.RLock()
 	defer h.clientsMux.RUnlock()
-	_, ok := h.clients[userID]
-	return ok
+	return len(h.clients[userID]) > 0
 }
 
-// Register adds a client to the hub
+// Register adds a client to the hub. It takes effect before returning, so
+// a replay started afterwards can't miss an event published in between.
 func (h *Hub) Register(client *Client) {
-	h.register <- client
+	h.clientsMux.Lock()
+	if h.clients[client.UserID] == nil {
+		h.clients[client.UserID] = make(map[*Client]struct{})
+	}
+	h.clients[client.UserID][client] = struct{}{}
+	connections := len(h.clients[client.UserID])
+	h.clientsMux.Unlock()
+	log.Printf("Client registered: %s (%d connections)", client.UserID, connections)
 }
 
 // Unregister removes a client from the hub
@@ -131,3 +193,53 @@ func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
 
+// deliver queues data on each client. Clients whose buffer is full can't
+// keep up and are disconnected.
+func (h *Hub) deliver(clients []*Client, data []byte) {
+	for _, client := range clients {
+		if !h.trySend(client, data) {
+			h.removeClient(client)
+		}
+	}
+}
+
+// trySend queues data without blocking. It reports false if the client's
+// buffer is full. Sends hold the read lock and closes the write lock, so a
+// send never hits a closed channel.
+func (h *Hub) trySend(client *Client, data []byte) bool {
+	h.clientsMux.RLock()
+	defer h.clientsMux.RUnlock()
+
+	if _, ok := h.clients[client.UserID][client]; !ok {
+		// Already gone
+		return true
+	}
+
+	select {
+	case client.Send <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// removeClient drops exactly this connection, leaving the user's other
+// connections alone, and closes its send channel once
+func (h *Hub) removeClient(client *Client) {
+	h.clientsMux.Lock()
+	defer h.clientsMux.Unlock()
+
+	clients, ok := h.clients[client.UserID]
+	if !ok {
+		return
+	}
+	if _, ok := clients[client]; !ok {
+		return
+	}
+
+	delete(clients, client)
+	if len(clients) == 0 {
+		delete(h.clients, client.UserID)
+	}
+	close(client.Send)
+}
@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSendToUserReachesEveryDevice(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	userID := uuid.New()
+	phone := NewClient(hub, nil, userID, "job_seeker")
+	browser := NewClient(hub, nil, userID, "job_seeker")
+	other := NewClient(hub, nil, uuid.New(), "recruiter")
+	hub.Register(phone)
+	hub.Register(browser)
+	hub.Register(other)
+
+	match := map[string]interface{}{
+		"type":    "match",
+		"payload": map[string]interface{}{"match_id": uuid.NewString()},
+	}
+	if err := hub.SendToUser(userID, match); err != nil {
+		t.Fatalf("SendToUser: %v", err)
+	}
+	expectEvent(t, phone, "match")
+	expectEvent(t, browser, "match")
+	expectNothing(t, other)
+
+	// Closing one device leaves the other connected
+	hub.Unregister(phone)
+	expectClosed(t, phone)
+	if !hub.IsOnline(userID) {
+		t.Fatal("user went offline with a device still connected")
+	}
+
+	if err := hub.SendToUser(userID, match); err != nil {
+		t.Fatalf("SendToUser: %v", err)
+	}
+	expectEvent(t, browser, "match")
+
+	hub.Unregister(browser)
+	expectClosed(t, browser)
+	if hub.IsOnline(userID) {
+		t.Error("user still online with no devices connected")
+	}
+}
+
+func expectEvent(t *testing.T, client *Client, eventType string) {
+	t.Helper()
+	select {
+	case data, ok := <-client.Send:
+		if !ok {
+			t.Fatalf("client was closed, want a %s event", eventType)
+		}
+		var msg OutgoingMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("bad event %s: %v", data, err)
+		}
+		if msg.Type != eventType {
+			t.Fatalf("got a %s event, want %s", msg.Type, eventType)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no %s event received", eventType)
+	}
+}
+
+func expectNothing(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case data := <-client.Send:
+		t.Fatalf("unexpected event %s", data)
+	default:
+	}
+}
+
+// expectClosed waits for the hub to close the client's send channel, which
+// it does once the client is removed
+func expectClosed(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case data, ok := <-client.Send:
+		if ok {
+			t.Fatalf("unexpected event %s", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("client was not removed")
+	}
+}
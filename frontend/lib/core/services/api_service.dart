@@ -179,10 +179,11 @@ class ApiService {
     return response.data;
   }
 
-  Future<List<dynamic>> getMessages(String matchId, {int limit = 50, int offset = 0}) async {
+  Future<List<dynamic>> getMessages(String matchId, {int limit = 50, String? before, String? after}) async {
     final response = await _dio.get('/chat/$matchId/messages', queryParameters: {
       'limit': limit,
-      'offset': offset,
+      if (before != null) 'before': before,
+      if (after != null) 'after': after,
     });
     return response.data;
   }